@@ -0,0 +1,58 @@
+package retry
+
+import "sync"
+
+// AbortToken makes a group of retriers fail fast together: once one of them
+// gives up on an error it doesn't retry, the others stop retrying promptly,
+// like on cancellation of their context. Create it with NewAbortToken and
+// share it with WithAbortToken.
+type AbortToken struct {
+	c *closer
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewAbortToken returns an untripped token.
+func NewAbortToken() *AbortToken {
+	return &AbortToken{c: newCloser()}
+}
+
+// WithAbortToken makes the retrier trip t when Run stops on an error
+// WouldRetry rejects, and stop retrying once t is tripped by another
+// retrier. Run then returns the error that tripped it.
+func WithAbortToken(t *AbortToken) Option {
+	return func(r *Retrier) {
+		r.abortToken = t
+	}
+}
+
+// Abort trips the token with err, unless it is already tripped.
+func (t *AbortToken) Abort(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	select {
+	case <-t.c.done():
+		return
+	default:
+	}
+	t.err = err
+	t.c.close()
+}
+
+// Err returns the error that tripped the token, nil if it isn't tripped.
+func (t *AbortToken) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+// done returns a channel closed once the token is tripped, nil for a nil
+// token.
+func (t *AbortToken) done() <-chan struct{} {
+	if t == nil {
+		return nil
+	}
+	return t.c.done()
+}
@@ -0,0 +1,58 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestAbortToken(t *testing.T) {
+	ctx := context.Background()
+	fatal := errors.New("fatal")
+	token := NewAbortToken()
+
+	errs := make(chan error, 3)
+	for i := 0; i < 2; i++ {
+		go func() {
+			r := New(time.Hour, time.Hour, WithAbortToken(token), TraceStop())
+			err := r.Run(ctx, func() error { return io.EOF })
+			if reason := r.StopTrace().Reason; reason != StopSibling {
+				err = errors.Join(err, errors.New(reason.String()))
+			}
+			errs <- err
+		}()
+	}
+	go func() {
+		r := New(10*time.Millisecond, 10*time.Millisecond, WithAbortToken(token), AbortOn(fatal))
+		var calls int
+		errs <- r.Run(ctx, func() error {
+			if calls++; calls == 3 {
+				return fatal
+			}
+			return io.EOF
+		})
+	}()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case err := <-errs:
+			if err != fatal {
+				t.Fatalf("got %v, want %v", err, fatal)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("siblings kept retrying after the fatal error")
+		}
+	}
+	if err := token.Err(); err != fatal {
+		t.Fatalf("token tripped with %v, want %v", err, fatal)
+	}
+
+	// Retriers sharing a tripped token don't even start.
+	var calls int
+	r := New(0, 0, WithAbortToken(token))
+	if err := r.Run(ctx, func() error { calls++; return nil }); err != fatal || calls != 0 {
+		t.Fatalf("got %v after %d calls, want %v without any", err, calls, fatal)
+	}
+}
@@ -0,0 +1,165 @@
+package retry
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// Bounds of the per-Wait scaling applied by LatencyAdaptive.
+const (
+	minLatencyGain = 0.5
+	maxLatencyGain = 2
+)
+
+// LatencyAdaptive modulates the backoff to keep the execution time of
+// attempts made by Run near target.
+//
+// Run tracks an exponentially weighted moving average of attempt latencies
+// (each new sample weighs 1/2). Every Wait then scales the delay by
+// average/target: a backend slower than target is retried less eagerly and a
+// faster one more eagerly. The scaling accumulates across waits, acting as an
+// integral controller. To keep the loop stable, a single Wait scales the delay
+// by at most [1/2, 2], and Floor and Ceil still bound the result.
+func LatencyAdaptive(target time.Duration) Option {
+	return func(r *Retrier) {
+		r.latencyTarget = target
+	}
+}
+
+// ErrSlowDown can be returned, or wrapped, by functions run by Run to signal
+// a backend asking its clients to slow down. See SlowDownCeil.
+var ErrSlowDown = errors.New("retry: slow down")
+
+// SlowDownError is implemented by errors that may signal sustained overload
+// of a backend, like ErrSlowDown does. See SlowDownCeil.
+type SlowDownError interface {
+	SlowDown() bool
+}
+
+// SlowDownCeil makes Run raise the ceiling of the backoff by factor, up to
+// max, whenever an attempt fails with ErrSlowDown or a SlowDownError
+// reporting true, so that the retrier backs off further from then on rather
+// than only once. Every successful attempt lowers the ceiling by factor again
+// until it is back at Ceil. A fixed delay, with Floor equal to Ceil and a Rate
+// of 1, is raised along with the ceiling. The raised ceiling survives Reset,
+// so that it carries over to the next run of the retrier, but not Clone. It
+// has no effect unless factor is above 1 and max above Ceil.
+func SlowDownCeil(factor float64, max time.Duration) Option {
+	return func(r *Retrier) {
+		r.slowDownFactor = factor
+		r.slowDownMax = max
+	}
+}
+
+// slowDown adjusts the ceiling boost of SlowDownCeil to the error of an
+// attempt.
+func (r *Retrier) slowDown(err error) {
+	if r.slowDownFactor <= 1 || r.slowDownMax <= r.Ceil {
+		return
+	}
+	if r.ceilBoost < 1 {
+		r.ceilBoost = 1
+	}
+
+	if err == nil {
+		r.ceilBoost = math.Max(1, r.ceilBoost/r.slowDownFactor)
+		return
+	}
+	var sd SlowDownError
+	if errors.Is(err, ErrSlowDown) || errors.As(err, &sd) && sd.SlowDown() {
+		// Stop growing once at max, so that relaxing starts right away.
+		if scale(r.Ceil, r.ceilBoost) < r.slowDownMax {
+			r.ceilBoost *= r.slowDownFactor
+		}
+	}
+}
+
+// latencyWindow is the number of recent latencies LatencyCeil considers.
+const latencyWindow = 128
+
+// LatencyCeil caps the delay at the given percentile, as a fraction in
+// (0, 1], of the execution times of the recent attempts made by Run. E.g.
+// 0.95 keeps the backoff from exceeding the p95 latency of the backend. Ceil
+// still applies, and the effective ceiling is never below Floor.
+func LatencyCeil(percentile float64) Option {
+	return func(r *Retrier) {
+		r.latencyPercentile = percentile
+	}
+}
+
+// ceil returns the effective ceiling of the delay.
+func (r *Retrier) ceil() time.Duration {
+	top := r.Ceil
+	if r.ceilBoost > 1 {
+		top = scale(top, r.ceilBoost)
+		if top > r.slowDownMax {
+			top = r.slowDownMax
+		}
+	}
+	if r.latencyPercentile <= 0 || len(r.latencies) == 0 {
+		return top
+	}
+
+	c := r.latencyQuantile(r.latencyPercentile)
+	if c > top {
+		c = top
+	}
+	if c < r.Floor {
+		c = r.Floor
+	}
+	return c
+}
+
+// latencyQuantile returns the q-quantile of the recent latencies.
+func (r *Retrier) latencyQuantile(q float64) time.Duration {
+	sorted := append([]time.Duration(nil), r.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	i := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+// observeLatency feeds the latency of an attempt to LatencyAdaptive and
+// LatencyCeil.
+func (r *Retrier) observeLatency(d time.Duration) {
+	if r.latencyPercentile > 0 {
+		if len(r.latencies) < latencyWindow {
+			r.latencies = append(r.latencies, d)
+		} else {
+			r.latencies[r.latencyPos] = d
+			r.latencyPos = (r.latencyPos + 1) % latencyWindow
+		}
+	}
+
+	if r.latencyTarget <= 0 {
+		return
+	}
+	if r.latency == 0 {
+		r.latency = d
+		return
+	}
+	r.latency = (r.latency + d) / 2
+}
+
+// latencyGain returns the factor the next delay is scaled by.
+func (r *Retrier) latencyGain() float64 {
+	if r.latencyTarget <= 0 || r.latency == 0 {
+		return 1
+	}
+	g := float64(r.latency) / float64(r.latencyTarget)
+	if g < minLatencyGain {
+		g = minLatencyGain
+	}
+	if g > maxLatencyGain {
+		g = maxLatencyGain
+	}
+	return g
+}
@@ -0,0 +1,152 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestLatencyAdaptive(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(time.Millisecond, time.Second, Rate(1), LatencyAdaptive(time.Millisecond))
+	r.Wait(ctx)
+
+	// The backend gets slower.
+	prev := r.Delay
+	for _, lat := range []time.Duration{2, 4, 8, 16} {
+		r.observeLatency(lat * time.Millisecond)
+		r.Wait(ctx)
+		if r.Delay <= prev {
+			t.Fatalf("latency %vms: delay %v did not grow from %v", lat, r.Delay, prev)
+		}
+		prev = r.Delay
+	}
+
+	// The backend recovers.
+	for i := 0; i < 8; i++ {
+		r.observeLatency(0)
+	}
+	r.Wait(ctx)
+	if r.Delay >= prev {
+		t.Fatalf("delay %v did not shrink from %v", r.Delay, prev)
+	}
+}
+
+func TestLatencyAdaptive_Run(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(time.Millisecond, time.Second, Rate(1), Attempts(4), LatencyAdaptive(time.Millisecond))
+
+	var lat time.Duration
+	_ = r.Run(ctx, func() error {
+		lat += 2 * time.Millisecond
+		time.Sleep(lat)
+		return context.DeadlineExceeded
+	})
+	if r.Delay <= time.Millisecond {
+		t.Fatalf("delay did not react to rising latency: %v", r.Delay)
+	}
+}
+
+func TestLatencyCeil(t *testing.T) {
+	r := New(time.Millisecond, time.Hour, LatencyCeil(0.95))
+	if c := r.ceil(); c != time.Hour {
+		t.Fatalf("ceil without samples = %v, want Ceil", c)
+	}
+
+	// Latencies of 1ms to 100ms.
+	for i := 100; i >= 1; i-- {
+		r.observeLatency(time.Duration(i) * time.Millisecond)
+	}
+	if c := r.ceil(); c != 95*time.Millisecond {
+		t.Fatalf("ceil = %v, want p95 of 95ms", c)
+	}
+
+	// The delay is capped at the tracked ceiling.
+	r.Delay = time.Minute
+	if d := r.next(); d != 95*time.Millisecond {
+		t.Fatalf("delay = %v, want 95ms", d)
+	}
+
+	// The backend gets faster; the window forgets the old latencies.
+	for i := 0; i < latencyWindow; i++ {
+		r.observeLatency(10 * time.Millisecond)
+	}
+	if c := r.ceil(); c != 10*time.Millisecond {
+		t.Fatalf("ceil = %v after speedup, want 10ms", c)
+	}
+
+	// Floor still bounds the effective ceiling.
+	r.Floor = 20 * time.Millisecond
+	if c := r.ceil(); c != 20*time.Millisecond {
+		t.Fatalf("ceil = %v, want Floor", c)
+	}
+}
+
+func TestSlowDownCeil(t *testing.T) {
+	var sleeps []time.Duration
+	schedule := func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	r := New(10*time.Millisecond, 10*time.Millisecond, Rate(2),
+		SlowDownCeil(2, 40*time.Millisecond), WithScheduler(schedule))
+
+	var calls int
+	err := r.Run(context.Background(), func() error {
+		calls++
+		if calls <= 4 {
+			return fmt.Errorf("backend: %w", ErrSlowDown)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []time.Duration{0, 20 * time.Millisecond, 40 * time.Millisecond, 40 * time.Millisecond}
+	for i, d := range want {
+		if sleeps[i] != d {
+			t.Fatalf("got sleeps %v, want %v", sleeps, want)
+		}
+	}
+
+	// Every success relaxes the ceiling back towards Ceil.
+	if c := r.ceil(); c != 20*time.Millisecond {
+		t.Fatalf("got ceil %v after a success, want 20ms", c)
+	}
+	r.slowDown(nil)
+	r.slowDown(nil)
+	if c := r.ceil(); c != 10*time.Millisecond {
+		t.Fatalf("got ceil %v, want Ceil back", c)
+	}
+
+	r.slowDown(io.EOF)
+	if c := r.ceil(); c != 10*time.Millisecond {
+		t.Fatalf("ordinary error raised the ceiling to %v", c)
+	}
+
+	// The raised ceiling carries over to the next run, also of fixed delays.
+	sleeps = nil
+	r = New(10*time.Millisecond, 10*time.Millisecond, Rate(1), Attempts(3),
+		SlowDownCeil(2, 40*time.Millisecond), WithScheduler(schedule))
+	_ = r.Run(context.Background(), func() error { return ErrSlowDown })
+	r.Reset()
+	r.Attempts = 2
+	_ = r.Run(context.Background(), func() error { return ErrSlowDown })
+	want = []time.Duration{0, 20 * time.Millisecond, 40 * time.Millisecond, 0, 40 * time.Millisecond}
+	if len(sleeps) != len(want) {
+		t.Fatalf("got sleeps %v, want %v", sleeps, want)
+	}
+	for i, d := range want {
+		if sleeps[i] != d {
+			t.Fatalf("got sleeps %v, want %v", sleeps, want)
+		}
+	}
+	if c := r.Clone().ceil(); c != 10*time.Millisecond {
+		t.Fatalf("clone inherited the raised ceiling %v", c)
+	}
+}
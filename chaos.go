@@ -0,0 +1,16 @@
+//go:build retrychaos
+
+package retry
+
+// ChaosHook makes Run call inject before every attempt, with the number of
+// the attempt starting at 1. A non-nil error fails the attempt with it
+// without calling the real function, nil lets the attempt proceed. It lets
+// tests and staging exercise retry handling deterministically.
+//
+// ChaosHook only exists in builds with the retrychaos tag, so that it can't
+// be active in production by accident.
+func ChaosHook(inject func(attempt int) error) Option {
+	return func(r *Retrier) {
+		r.chaos = inject
+	}
+}
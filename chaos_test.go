@@ -0,0 +1,34 @@
+//go:build retrychaos
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestChaosHook(t *testing.T) {
+	injected := errors.New("injected")
+	r := New(0, 0, ChaosHook(func(attempt int) error {
+		if attempt <= 2 {
+			return injected
+		}
+		return nil
+	}), CoalesceErrors())
+
+	var calls int
+	err := r.Run(context.Background(), func() error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("real function called %d times, want once", calls)
+	}
+	if errs := r.Errors(); len(errs) != 1 || errs[0].Err != injected || errs[0].Count != 2 {
+		t.Fatalf("got errors %+v, want the injected one twice", errs)
+	}
+}
@@ -0,0 +1,146 @@
+package retry
+
+import (
+	"context"
+	"errors"
+)
+
+// Retryable is implemented by errors that know whether they are worth
+// retrying. Errors reporting false are never retried.
+type Retryable interface {
+	Retryable() bool
+}
+
+// Cond makes Run retry only the errors for which cond returns true.
+func Cond(cond func(err error) bool) Option {
+	return func(r *Retrier) {
+		r.cond = cond
+	}
+}
+
+// RetryOn makes Run retry only errors matching one of targets, as reported
+// by errors.Is.
+func RetryOn(targets ...error) Option {
+	return func(r *Retrier) {
+		r.retryOn = append(r.retryOn, targets...)
+	}
+}
+
+// AbortOn makes Run give up on errors matching one of targets, as reported
+// by errors.Is.
+func AbortOn(targets ...error) Option {
+	return func(r *Retrier) {
+		r.abortOn = append(r.abortOn, targets...)
+	}
+}
+
+// AbortOnContextError makes Run give up on errors wrapping context.Canceled
+// or context.DeadlineExceeded, e.g. fmt.Errorf("query: %w", ctx.Err()), when
+// abort is true. Such errors usually stem from a context of the operation
+// that ended and retrying them is futile. By default they are retried like
+// any other error.
+func AbortOnContextError(abort bool) Option {
+	return func(r *Retrier) {
+		r.abortOnCtxErr = abort
+	}
+}
+
+// Joined is a policy for errors joining several errors, like those of
+// errors.Join. See JoinedPolicy.
+type Joined int
+
+const (
+	// JoinedAny retries a joined error if any of its members would be
+	// retried.
+	JoinedAny Joined = iota + 1
+	// JoinedAll retries a joined error only if all of its members would be
+	// retried.
+	JoinedAll
+)
+
+// JoinedPolicy makes Run judge errors implementing Unwrap() []error by their
+// members, each classified on its own as by WouldRetry, nested ones
+// included, and combine the verdicts by p. Without it, a joined error is
+// classified as a whole: it matches AbortOn and RetryOn if any member does.
+func JoinedPolicy(p Joined) Option {
+	return func(r *Retrier) {
+		r.joined = p
+	}
+}
+
+// WouldRetry reports whether Run would retry an attempt failing with err.
+// It has no side effects and does not consume attempts.
+//
+// The conditions are evaluated in order, and the first one that applies
+// decides:
+//
+//  1. nil is never retried, it is a success. With JoinedPolicy, joined
+//     errors are decided by their members.
+//  2. Errors matching AbortOn are not retried.
+//  3. With AbortOnContextError, wrapped context errors are not retried.
+//  4. ErrNotGoodEnough is retried.
+//  5. Errors implementing Retryable are retried if they say so.
+//  6. With RetryOn set, only matching errors are retried.
+//  7. With Cond set, it decides.
+//  8. Otherwise the error is retried.
+func (r *Retrier) WouldRetry(err error) bool {
+	if err == nil {
+		return false
+	}
+	if j, ok := err.(interface{ Unwrap() []error }); ok && r.joined != 0 {
+		return r.wouldRetryJoined(j.Unwrap())
+	}
+	if matchAny(err, r.abortOn) {
+		return false
+	}
+	if r.abortOnCtxErr && isContextError(err) {
+		return false
+	}
+	if errors.Is(err, ErrNotGoodEnough) {
+		return true
+	}
+
+	var re Retryable
+	if errors.As(err, &re) && !re.Retryable() {
+		return false
+	}
+
+	if len(r.retryOn) > 0 && !matchAny(err, r.retryOn) {
+		return false
+	}
+	if r.cond != nil {
+		return r.cond(err)
+	}
+	return true
+}
+
+// wouldRetryJoined combines the verdicts on the members of a joined error.
+func (r *Retrier) wouldRetryJoined(errs []error) bool {
+	var retry, judged int
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		judged++
+		if r.WouldRetry(err) {
+			retry++
+		}
+	}
+	if r.joined == JoinedAll {
+		return judged > 0 && retry == judged
+	}
+	return retry > 0
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func matchAny(err error, targets []error) bool {
+	for _, target := range targets {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
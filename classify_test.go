@@ -0,0 +1,142 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+type retryableError bool
+
+func (e retryableError) Error() string   { return fmt.Sprintf("retryable: %v", bool(e)) }
+func (e retryableError) Retryable() bool { return bool(e) }
+
+func TestWouldRetry(t *testing.T) {
+	errOther := errors.New("other")
+
+	for _, tc := range []struct {
+		name string
+		opts []Option
+		err  error
+		want bool
+	}{
+		{"nil", nil, nil, false},
+		{"default", nil, io.EOF, true},
+		{"abort on", []Option{AbortOn(io.EOF)}, fmt.Errorf("read: %w", io.EOF), false},
+		{"abort on other", []Option{AbortOn(io.EOF)}, errOther, true},
+		{"retry on", []Option{RetryOn(io.EOF)}, fmt.Errorf("read: %w", io.EOF), true},
+		{"retry on other", []Option{RetryOn(io.EOF)}, errOther, false},
+		{"abort wins over retry on", []Option{RetryOn(io.EOF), AbortOn(io.EOF)}, io.EOF, false},
+		{"cond", []Option{Cond(func(err error) bool { return err == errOther })}, errOther, true},
+		{"cond rejects", []Option{Cond(func(err error) bool { return err == errOther })}, io.EOF, false},
+		{"retryable", nil, retryableError(true), true},
+		{"not retryable", nil, fmt.Errorf("op: %w", retryableError(false)), false},
+		{"not retryable wins over cond", []Option{Cond(func(error) bool { return true })}, retryableError(false), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := New(0, 0, tc.opts...)
+			if got := r.WouldRetry(tc.err); got != tc.want {
+				t.Fatalf("WouldRetry(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWouldRetry_NoSideEffects(t *testing.T) {
+	r := New(time.Millisecond, time.Second, Attempts(3))
+	for i := 0; i < 10; i++ {
+		r.WouldRetry(io.EOF)
+	}
+	if r.Attempts != 3 || r.Delay != 0 {
+		t.Fatalf("WouldRetry changed the retrier: attempts %d, delay %v", r.Attempts, r.Delay)
+	}
+}
+
+func TestRun_AbortOn(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(0, 0, AbortOn(io.ErrUnexpectedEOF))
+
+	var calls int
+	err := r.Run(ctx, func() error {
+		calls++
+		if calls == 3 {
+			return io.ErrUnexpectedEOF
+		}
+		return io.EOF
+	})
+	if err != io.ErrUnexpectedEOF || calls != 3 {
+		t.Fatalf("err = %v, calls = %d; want %v, 3", err, calls, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestRun_AbortOnContextError(t *testing.T) {
+	ctx := context.Background()
+	wrapped := fmt.Errorf("query: %w", context.DeadlineExceeded)
+
+	for _, tt := range []struct {
+		abort bool
+		calls int
+	}{
+		{false, 3},
+		{true, 1},
+	} {
+		r := New(0, 0, Attempts(3), AbortOnContextError(tt.abort))
+		var calls int
+		err := r.Run(ctx, func() error {
+			calls++
+			return wrapped
+		})
+		if err != wrapped || calls != tt.calls {
+			t.Fatalf("abort %v: err = %v, calls = %d; want %v, %d", tt.abort, err, calls, wrapped, tt.calls)
+		}
+	}
+}
+
+func TestJoinedPolicy(t *testing.T) {
+	fatal := errors.New("fatal")
+	mixed := errors.Join(io.EOF, fatal)
+	nested := errors.Join(io.EOF, errors.Join(io.ErrUnexpectedEOF, fatal))
+	retryable := errors.Join(io.EOF, io.ErrUnexpectedEOF)
+
+	for _, tc := range []struct {
+		name   string
+		policy Joined
+		err    error
+		want   bool
+	}{
+		{"whole", 0, mixed, false},
+		{"any mixed", JoinedAny, mixed, true},
+		{"all mixed", JoinedAll, mixed, false},
+		{"any nested", JoinedAny, nested, true},
+		{"all nested", JoinedAll, nested, false},
+		{"all retryable", JoinedAll, retryable, true},
+		{"any fatal", JoinedAny, errors.Join(fatal, fatal), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := []Option{AbortOn(fatal)}
+			if tc.policy != 0 {
+				opts = append(opts, JoinedPolicy(tc.policy))
+			}
+			r := New(0, 0, opts...)
+			if got := r.WouldRetry(tc.err); got != tc.want {
+				t.Fatalf("WouldRetry(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+
+	r := New(0, 0, RetryOn(io.EOF, io.ErrUnexpectedEOF), JoinedPolicy(JoinedAll))
+	var calls int
+	err := r.Run(context.Background(), func() error {
+		if calls++; calls < 3 {
+			return retryable
+		}
+		return errors.Join(io.EOF, errors.New("unknown"))
+	})
+	if calls != 3 || err == nil {
+		t.Fatalf("got %v after %d calls, want to give up on the third", err, calls)
+	}
+}
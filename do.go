@@ -0,0 +1,201 @@
+package retry
+
+import (
+	"context"
+	"errors"
+)
+
+// Any runs fns under the policy of r until one of them succeeds.
+//
+// Every attempt calls fns sequentially, in order, and stops at the first one
+// returning nil; r backs off only when all of them failed. On give up Any
+// returns the errors of the last attempt joined together. On success
+// WinnerInfo of r reports which of fns succeeded.
+func Any(r *Retrier, ctx context.Context, fns ...func() error) error {
+	if len(fns) == 0 {
+		return ErrNoAttempts
+	}
+	for _, fn := range fns {
+		if fn == nil {
+			return ErrNilFunc
+		}
+	}
+	var target int
+	err := r.Run(ctx, func() error {
+		errs := make([]error, 0, len(fns))
+		for i, fn := range fns {
+			err := fn()
+			if err == nil {
+				target = i
+				return nil
+			}
+			errs = append(errs, err)
+		}
+		return errors.Join(errs...)
+	})
+	if r.won {
+		r.winner.Target = target
+	}
+	return err
+}
+
+// DoState runs fn under the policy of r, threading a state between attempts.
+// The first attempt receives initial and every following one the state
+// returned by its predecessor. DoState returns the last state along with the
+// error of Run.
+func DoState[S any](r *Retrier, ctx context.Context, initial S, fn func(S) (S, error)) (S, error) {
+	if fn == nil {
+		return initial, ErrNilFunc
+	}
+
+	state := initial
+	err := r.Run(ctx, func() error {
+		var err error
+		state, err = fn(state)
+		return err
+	})
+	return state, err
+}
+
+// Locker is a lock that can be acquired with a context, e.g. a distributed
+// lock or a lease.
+type Locker interface {
+	Lock(ctx context.Context) error
+	Unlock()
+}
+
+// DoWithLock runs fn under the policy of r while holding lock. Every attempt
+// acquires lock before calling fn and releases it afterwards. Failing to
+// acquire the lock fails the attempt, so lock contention is backed off like
+// any other error.
+func DoWithLock(r *Retrier, ctx context.Context, lock Locker, fn func() error) error {
+	if fn == nil {
+		return ErrNilFunc
+	}
+	return r.Run(ctx, func() error {
+		if err := lock.Lock(ctx); err != nil {
+			return err
+		}
+		defer lock.Unlock()
+		return fn()
+	})
+}
+
+// Do runs fn under the policy of r and returns the value of the successful
+// attempt, or the value of the last attempt along with the error of Run.
+func Do[T any](r *Retrier, ctx context.Context, fn func() (T, error)) (T, error) {
+	var v T
+	if fn == nil {
+		return v, ErrNilFunc
+	}
+	err := r.Run(ctx, func() error {
+		var err error
+		v, err = fn()
+		return err
+	})
+	return v, err
+}
+
+// DoAll runs fn under the policy of r like Do, but returns the values of all
+// attempts in order, failed ones included, along with the error of Run. Only
+// the last MaxRetained values are kept, or the last 1024 when MaxRetained is
+// zero.
+func DoAll[T any](r *Retrier, ctx context.Context, fn func() (T, error)) ([]T, error) {
+	if fn == nil {
+		return nil, ErrNilFunc
+	}
+	n := r.MaxRetained
+	if n <= 0 {
+		n = defaultRetainedDelays
+	}
+	var vs []T
+	err := r.Run(ctx, func() error {
+		v, err := fn()
+		vs = retain(append(vs, v), n)
+		return err
+	})
+	return vs, err
+}
+
+// ErrNotGoodEnough fails the attempts of DoBest whose result scored too low.
+// WouldRetry retries it regardless of RetryOn and Cond.
+var ErrNotGoodEnough = errors.New("retry: result not good enough")
+
+// DoBest runs fn under the policy of r until it returns a result that score
+// rates at least good, and otherwise returns the best-scoring result of all
+// successful attempts once r gives up, with a nil error. Only if no attempt
+// succeeded does it return the error of Run. Attempts scoring below good fail
+// with ErrNotGoodEnough, as seen by events, MaxSameError and RecentAttempts.
+func DoBest[T any](r *Retrier, ctx context.Context, fn func() (T, error), score func(T) float64, good float64) (T, error) {
+	var (
+		best     T
+		bestRank float64
+		found    bool
+	)
+	if fn == nil || score == nil {
+		return best, ErrNilFunc
+	}
+
+	err := r.Run(ctx, func() error {
+		v, err := fn()
+		if err != nil {
+			return err
+		}
+		s := score(v)
+		if !found || s > bestRank {
+			best, bestRank, found = v, s, true
+		}
+		if s < good {
+			return ErrNotGoodEnough
+		}
+		return nil
+	})
+	if found {
+		return best, nil
+	}
+	return best, err
+}
+
+// Acquire retries opening a resource under the policy of r and returns it
+// once verify accepts it. A resource that fails verify is closed before the
+// next attempt, and so is one left open when the run fails after all, e.g.
+// because SlowAttemptThreshold rejected an otherwise successful attempt, so
+// that no half-open resources leak across attempts. A nil verify accepts
+// every resource that opened.
+func Acquire[T any](r *Retrier, ctx context.Context, open func() (T, error), verify func(T) error, close func(T)) (T, error) {
+	var (
+		v    T
+		zero T
+		held bool
+	)
+	if open == nil || close == nil {
+		return v, ErrNilFunc
+	}
+
+	err := r.Run(ctx, func() error {
+		if held {
+			close(v)
+			v, held = zero, false
+		}
+
+		var err error
+		if v, err = open(); err != nil {
+			return err
+		}
+		held = true
+
+		if verify != nil {
+			if err := verify(v); err != nil {
+				close(v)
+				v, held = zero, false
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil && held {
+		close(v)
+		v = zero
+	}
+	return v, err
+}
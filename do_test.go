@@ -0,0 +1,356 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestAny(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(time.Millisecond, time.Millisecond)
+
+	var first, second int
+	err := Any(r, ctx,
+		func() error {
+			first++
+			return io.EOF
+		},
+		func() error {
+			second++
+			if second < 3 {
+				return io.ErrUnexpectedEOF
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first != 3 || second != 3 {
+		t.Fatalf("calls = %d, %d; want 3, 3", first, second)
+	}
+}
+
+func TestAny_Exhausted(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(0, 0, Attempts(2))
+	err := Any(r, ctx,
+		func() error { return io.EOF },
+		func() error { return io.ErrUnexpectedEOF },
+	)
+	if !errors.Is(err, io.EOF) || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := r.WinnerInfo(); ok {
+		t.Fatalf("winner reported for a failed run")
+	}
+}
+
+func TestAny_Winner(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(0, 0)
+	var calls int
+	replica := func(healthyFrom int) func() error {
+		return func() error {
+			calls++
+			if calls < healthyFrom {
+				return io.EOF
+			}
+			return nil
+		}
+	}
+	// Attempt 1 makes calls 1-3, attempt 2 calls 4-5, succeeding at replica 1.
+	err := Any(r, ctx, replica(99), replica(5), replica(99))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w, ok := r.WinnerInfo()
+	if !ok {
+		t.Fatalf("no winner reported for a successful run")
+	}
+	if w != (Winner{Attempt: 2, Target: 1}) {
+		t.Fatalf("got winner %+v, want attempt 2 of target 1", w)
+	}
+}
+
+func TestDoState(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(0, 0)
+	got, err := DoState(r, ctx, []int{0}, func(s []int) ([]int, error) {
+		s = append(s, s[len(s)-1]+1)
+		if len(s) < 4 {
+			return s, io.EOF
+		}
+		return s, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 4 || got[3] != 3 {
+		t.Fatalf("state = %v, want [0 1 2 3]", got)
+	}
+
+	r = New(0, 0, Attempts(3))
+	n, err := DoState(r, ctx, 10, func(n int) (int, error) {
+		return n + 1, io.EOF
+	})
+	if n != 13 || !errors.Is(err, io.EOF) {
+		t.Fatalf("DoState = %d, %v; want 13, EOF", n, err)
+	}
+}
+
+var errContended = errors.New("lock contended")
+
+type mockLock struct {
+	contended int
+	held      bool
+	locks     int
+	unlocks   int
+}
+
+func (l *mockLock) Lock(ctx context.Context) error {
+	l.locks++
+	if l.locks <= l.contended {
+		return errContended
+	}
+	l.held = true
+	return nil
+}
+
+func (l *mockLock) Unlock() {
+	l.unlocks++
+	l.held = false
+}
+
+func TestDoWithLock(t *testing.T) {
+	ctx := context.Background()
+
+	lock := &mockLock{contended: 2}
+	r := New(time.Millisecond, time.Millisecond)
+
+	var calls int
+	err := DoWithLock(r, ctx, lock, func() error {
+		calls++
+		if !lock.held {
+			t.Fatalf("fn called without holding the lock")
+		}
+		if calls == 1 {
+			return io.EOF
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lock.locks != 4 || calls != 2 {
+		t.Fatalf("locks = %d, calls = %d; want 4, 2", lock.locks, calls)
+	}
+	if lock.unlocks != 2 || lock.held {
+		t.Fatalf("lock not released after each attempt: %d unlocks", lock.unlocks)
+	}
+}
+
+func TestDoWithLock_Contended(t *testing.T) {
+	ctx := context.Background()
+
+	lock := &mockLock{contended: 10}
+	r := New(0, 0, Attempts(3))
+
+	err := DoWithLock(r, ctx, lock, func() error {
+		t.Fatalf("fn called without the lock")
+		return nil
+	})
+	if !errors.Is(err, errContended) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDo(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(0, 0)
+
+	var calls int
+	v, err := Do(r, ctx, func() (int, error) {
+		calls++
+		if calls < 3 {
+			return 0, io.EOF
+		}
+		return 42, nil
+	})
+	if v != 42 || err != nil {
+		t.Fatalf("Do = %v, %v; want 42, nil", v, err)
+	}
+}
+
+func TestNilFunc(t *testing.T) {
+	ctx := context.Background()
+	r := New(0, 0)
+
+	for name, call := range map[string]func() error{
+		"Run": func() error { return r.Run(ctx, nil) },
+		"RunWithDeadlines": func() error {
+			return r.RunWithDeadlines(ctx, nil, nil)
+		},
+		"Wrap": func() error { return Wrap(r, nil)(ctx) },
+		"Any":  func() error { return Any(r, ctx, func() error { return io.EOF }, nil) },
+		"Do": func() error {
+			_, err := Do[int](r, ctx, nil)
+			return err
+		},
+		"DoState": func() error {
+			_, err := DoState[int](r, ctx, 0, nil)
+			return err
+		},
+		"DoMetered": func() error {
+			_, err := DoMetered[int](r, ContextWithMetrics(ctx, new(Metrics)), nil)
+			return err
+		},
+		"DoWithLock": func() error { return DoWithLock(r, ctx, &mockLock{}, nil) },
+	} {
+		if err := call(); !errors.Is(err, ErrNilFunc) {
+			t.Fatalf("%s: err = %v, want ErrNilFunc", name, err)
+		}
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	ctx := context.Background()
+
+	var opened, closed int
+	open := func() (int, error) {
+		opened++
+		if opened == 1 {
+			return 0, io.EOF
+		}
+		return opened, nil
+	}
+	close := func(int) { closed++ }
+
+	r := New(0, 0)
+	v, err := Acquire(r, ctx, open, func(v int) error {
+		if v < 4 {
+			return io.ErrUnexpectedEOF
+		}
+		return nil
+	}, close)
+	if err != nil || v != 4 {
+		t.Fatalf("got %d, %v; want 4, nil", v, err)
+	}
+	// Resources 2 and 3 failed verification, the first one never opened.
+	if closed != 2 {
+		t.Fatalf("closed %d resources, want 2", closed)
+	}
+
+	opened, closed = 0, 0
+	r = New(0, 0, Attempts(3))
+	_, err = Acquire(r, ctx, open, func(int) error { return io.ErrUnexpectedEOF }, close)
+	if err != io.ErrUnexpectedEOF {
+		t.Fatalf("got %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if closed != opened-1 {
+		t.Fatalf("closed %d of %d opened resources", closed, opened-1)
+	}
+}
+
+func TestAcquire_SlowAttempt(t *testing.T) {
+	var closed int
+	r := New(0, 0, Attempts(2), SlowAttemptThreshold(time.Nanosecond))
+	_, err := Acquire(r, context.Background(), func() (int, error) {
+		time.Sleep(time.Millisecond)
+		return 1, nil
+	}, nil, func(int) { closed++ })
+	if err != ErrSlowAttempt {
+		t.Fatalf("got %v, want %v", err, ErrSlowAttempt)
+	}
+	if closed != 2 {
+		t.Fatalf("closed %d resources, want 2", closed)
+	}
+}
+
+func TestDoBest(t *testing.T) {
+	ctx := context.Background()
+	score := func(v int) float64 { return float64(v) }
+
+	results := []int{3, 1, 7, 5}
+	var i int
+	next := func() (int, error) {
+		v := results[i%len(results)]
+		i++
+		if v == 1 {
+			return 0, io.EOF
+		}
+		return v, nil
+	}
+
+	r := New(0, 0, Attempts(4))
+	got, err := DoBest(r, ctx, next, score, 10)
+	if err != nil || got != 7 {
+		t.Fatalf("got %d, %v; want the best result 7", got, err)
+	}
+
+	i = 0
+	r = New(0, 0, Attempts(4))
+	got, err = DoBest(r, ctx, next, score, 6)
+	if err != nil || got != 7 || i != 3 {
+		t.Fatalf("got %d, %v after %d attempts; want 7 after 3", got, err, i)
+	}
+
+	i = 0
+	r = New(0, 0, Attempts(4), RetryOn(io.EOF))
+	got, err = DoBest(r, ctx, next, score, 6)
+	if err != nil || got != 7 || i != 3 {
+		t.Fatalf("RetryOn: got %d, %v after %d attempts; want 7 after 3", got, err, i)
+	}
+
+	r = New(0, 0, Attempts(2))
+	_, err = DoBest(r, ctx, func() (int, error) { return 0, io.EOF }, score, 1)
+	if err != io.EOF {
+		t.Fatalf("got %v, want %v", err, io.EOF)
+	}
+}
+
+func TestDoAll(t *testing.T) {
+	ctx := context.Background()
+
+	var i int
+	fn := func() (int, error) {
+		i++
+		if i < 3 {
+			return i * 10, io.EOF
+		}
+		return i * 10, nil
+	}
+	got, err := DoAll(New(0, 0, Attempts(5)), ctx, fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{10, 20, 30}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for k := range want {
+		if got[k] != want[k] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	i = 0
+	got, err = DoAll(New(0, 0, MaxRetained(2)), ctx, func() (int, error) {
+		i++
+		if i < 5 {
+			return i, io.EOF
+		}
+		return i, nil
+	})
+	if err != nil || len(got) != 2 || got[0] != 4 || got[1] != 5 {
+		t.Fatalf("got %v, %v; want the last two values [4 5]", got, err)
+	}
+}
@@ -0,0 +1,115 @@
+package retry
+
+import "time"
+
+// EventKind is the kind of an Event.
+type EventKind int
+
+const (
+	// EventRetry is emitted when a failed attempt is about to be retried.
+	EventRetry EventKind = iota
+	// EventSuccess is emitted when an attempt succeeds.
+	EventSuccess
+	// EventGiveUp is emitted when Run stops without success.
+	EventGiveUp
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventRetry:
+		return "retry"
+	case EventSuccess:
+		return "success"
+	case EventGiveUp:
+		return "giveup"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes a step of Run.
+type Event struct {
+	Kind EventKind
+
+	// Attempt is the number of the attempt the event refers to, starting at 1.
+	Attempt int
+
+	// Delay is the delay slept before the attempt.
+	Delay time.Duration
+
+	// Err is the error of the previous attempt for EventRetry and the error
+	// returned by Run for EventGiveUp.
+	Err error
+
+	// fields are those set with WithFields, shared by all events of the
+	// retrier. A pointer keeps events comparable.
+	fields *map[string]any
+}
+
+// Fields returns a copy of the fields attached to the event with WithFields.
+func (e Event) Fields() map[string]any {
+	if e.fields == nil {
+		return nil
+	}
+	fields := make(map[string]any, len(*e.fields))
+	for k, v := range *e.fields {
+		fields[k] = v
+	}
+	return fields
+}
+
+// EventChan makes Run send its events to ch.
+//
+// Sends never block: when ch is full the event is dropped and counted, see
+// DroppedEvents. Slow consumers therefore lose events instead of stalling the
+// retry loop.
+func EventChan(ch chan<- Event) Option {
+	return func(r *Retrier) {
+		r.events = ch
+	}
+}
+
+// WithFields attaches fields to every event the retrier emits, e.g. the
+// operation, endpoint or tenant, so that consumers get the same metadata
+// without capturing it themselves. The map is copied; the fields stay fixed
+// for the lifetime of the retrier and its clones. Later calls add to the
+// fields of earlier ones.
+func WithFields(fields map[string]any) Option {
+	return func(r *Retrier) {
+		var merged map[string]any
+		if r.fields != nil {
+			merged = (Event{fields: r.fields}).Fields()
+		} else {
+			merged = make(map[string]any, len(fields))
+		}
+		for k, v := range fields {
+			merged[k] = v
+		}
+		r.fields = &merged
+	}
+}
+
+// DroppedEvents returns the number of events dropped because the channel
+// passed to EventChan was full.
+func (r *Retrier) DroppedEvents() int {
+	return r.droppedEvents
+}
+
+func (r *Retrier) emit(kind EventKind, err error) {
+	if r.events == nil {
+		return
+	}
+
+	e := Event{
+		Kind:    kind,
+		Attempt: r.attempt,
+		Delay:   r.slept,
+		Err:     err,
+		fields:  r.fields,
+	}
+	select {
+	case r.events <- e:
+	default:
+		r.droppedEvents++
+	}
+}
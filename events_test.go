@@ -0,0 +1,104 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestEventChan(t *testing.T) {
+	ctx := context.Background()
+	ch := make(chan Event, 16)
+
+	r := New(time.Millisecond, time.Millisecond, EventChan(ch))
+
+	var calls int
+	_ = r.Run(ctx, func() error {
+		calls++
+		if calls < 3 {
+			return io.EOF
+		}
+		return nil
+	})
+	close(ch)
+
+	var got []Event
+	for e := range ch {
+		got = append(got, e)
+	}
+
+	want := []Event{
+		{Kind: EventRetry, Attempt: 2, Delay: time.Millisecond, Err: io.EOF},
+		{Kind: EventRetry, Attempt: 3, Delay: time.Millisecond, Err: io.EOF},
+		{Kind: EventSuccess, Attempt: 3, Delay: time.Millisecond},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("event %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestEventChan_GiveUp(t *testing.T) {
+	ctx := context.Background()
+	ch := make(chan Event, 16)
+
+	r := New(0, 0, Attempts(2), EventChan(ch))
+	_ = r.Run(ctx, func() error { return io.EOF })
+	close(ch)
+
+	var last Event
+	for e := range ch {
+		last = e
+	}
+	if last.Kind != EventGiveUp || !errors.Is(last.Err, io.EOF) {
+		t.Fatalf("last event = %+v, want giveup", last)
+	}
+}
+
+func TestEventChan_Drop(t *testing.T) {
+	ctx := context.Background()
+	ch := make(chan Event, 1)
+
+	r := New(0, 0, Attempts(5), EventChan(ch))
+	_ = r.Run(ctx, func() error { return io.EOF })
+
+	// 4 retries and a give up, of which only the first fits into the channel.
+	if n := r.DroppedEvents(); n != 4 {
+		t.Fatalf("dropped %d events, want 4", n)
+	}
+}
+
+func TestWithFields(t *testing.T) {
+	ctx := context.Background()
+	ch := make(chan Event, 16)
+
+	fields := map[string]any{"operation": "fetch", "tenant": 42}
+	r := New(0, 0, Attempts(2), EventChan(ch), WithFields(fields),
+		WithFields(map[string]any{"endpoint": "/v1/items"}))
+	fields["operation"] = "changed"
+
+	_ = r.Run(ctx, func() error { return io.EOF })
+	close(ch)
+
+	var n int
+	for e := range ch {
+		n++
+		got := e.Fields()
+		if len(got) != 3 || got["operation"] != "fetch" || got["tenant"] != 42 || got["endpoint"] != "/v1/items" {
+			t.Fatalf("%v event has fields %v", e.Kind, got)
+		}
+		got["tenant"] = 0
+		if e.Fields()["tenant"] != 42 {
+			t.Fatalf("fields of an event were modified")
+		}
+	}
+	if n != 2 {
+		t.Fatalf("got %d events, want a retry and a give up", n)
+	}
+}
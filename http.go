@@ -0,0 +1,199 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ErrBodyNotRewindable is returned by DoRequest when a request with a body
+// needs to be retried, but the body can't be rewound because the request has
+// no GetBody.
+var ErrBodyNotRewindable = errors.New("retry: request body can't be rewound")
+
+// maxDrain bounds how much of a discarded response body is read so that the
+// connection can be reused.
+const maxDrain = 64 << 10
+
+// DoRequest sends req with client under the policy of r and returns the first
+// response classify doesn't ask to retry. classify is called with the result
+// of every attempt and reports whether to retry it; when nil, transport
+// errors and responses with status 429 or 5xx are retried. Every attempt is
+// sent with a context that ends with the run: when its Timeout passes or r is
+// closed or aborted, a request in flight is cancelled.
+//
+// Before every retry the request body is rewound with req.GetBody, which
+// http.NewRequest sets for common body types; without it, DoRequest fails
+// with ErrBodyNotRewindable rather than resend a consumed body. Discarded
+// responses are drained and closed, and their Retry-After headers, or those
+// picked by HeaderBackoff, override the next delay. A nil client means
+// http.DefaultClient.
+//
+// A response is returned only along with a nil error. If the run goes on
+// after a response was kept, e.g. with SlowAttemptThreshold, or fails
+// anyway, the response is discarded too. With AcceptLastOnExhaustion, the
+// error of the last attempt is returned in place of a nil one.
+func DoRequest(r *Retrier, ctx context.Context, client *http.Client, req *http.Request, classify func(*http.Response, error) bool) (*http.Response, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if classify == nil {
+		classify = retryableResponse
+	}
+	hasBody := req.Body != nil && req.Body != http.NoBody
+
+	var (
+		resp *http.Response
+		sent bool
+		last error
+	)
+	attempt := func(actx context.Context) error {
+		if resp != nil {
+			// The run went on after a kept response, e.g. as it was too slow.
+			discard(resp)
+			resp = nil
+		}
+
+		var body io.ReadCloser
+		if sent && hasBody {
+			if req.GetBody == nil {
+				return permanent{ErrBodyNotRewindable}
+			}
+			var err error
+			if body, err = req.GetBody(); err != nil {
+				return permanent{err}
+			}
+		}
+		sent = true
+
+		// The request is cancelled with the attempt, but a response kept
+		// must stay readable after the run ended.
+		rctx, cancel := context.WithCancel(ctx)
+		unlink := cancelWith(actx, cancel)
+		rq := req.WithContext(rctx)
+		if body != nil {
+			rq.Body = body
+		}
+		res, err := client.Do(rq)
+		unlink()
+		var uerr *url.Error
+		if err != nil && actx.Err() != nil && errors.As(err, &uerr) && uerr.Err == context.Canceled {
+			// Tell why the attempt was cancelled, e.g. the Timeout of the run.
+			uerr.Err = actx.Err()
+		}
+
+		if !classify(res, err) {
+			if err != nil {
+				cancel()
+				return permanent{err}
+			}
+			res.Body = cancelOnClose{res.Body, cancel}
+			resp = res
+			return nil
+		}
+		if err != nil {
+			cancel()
+			return err
+		}
+		defer cancel()
+
+		if d, ok := r.backoffHint(res.Header); ok {
+			r.overrideNext(d)
+		}
+		discard(res)
+		return fmt.Errorf("retry: %s %s: %s", req.Method, req.URL.Redacted(), res.Status)
+	}
+	err := r.run(ctx, func(actx context.Context) error {
+		last = attempt(actx)
+		return last
+	}, true)
+	if err == nil && resp == nil {
+		// AcceptLastOnExhaustion accepted a discarded response.
+		err = last
+	}
+	if err != nil && resp != nil {
+		discard(resp)
+		resp = nil
+	}
+
+	var p permanent
+	if errors.As(err, &p) {
+		err = p.error
+	}
+	return resp, err
+}
+
+// cancelWith calls cancel when ctx ends, until the returned function is
+// called.
+func cancelWith(ctx context.Context, cancel context.CancelFunc) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			cancel()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+// cancelOnClose cancels the context of a kept response once its body is
+// closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// HeaderBackoff makes DoRequest take the delay before retrying a discarded
+// response from its headers with extract, for APIs announcing it in headers
+// other than Retry-After, e.g. X-RateLimit-Reset. The delay extract reports
+// overrides the computed backoff, clamped to Ceil; when it reports false,
+// Retry-After is still honored.
+func HeaderBackoff(extract func(http.Header) (time.Duration, bool)) Option {
+	return func(r *Retrier) {
+		r.headerBackoff = extract
+	}
+}
+
+// backoffHint returns the delay the headers of a response ask for.
+func (r *Retrier) backoffHint(h http.Header) (time.Duration, bool) {
+	if r.headerBackoff != nil {
+		if d, ok := r.headerBackoff(h); ok {
+			return d, true
+		}
+	}
+	return r.RetryAfter(h.Get("Retry-After"))
+}
+
+// retryableResponse is the default classifier of DoRequest.
+func retryableResponse(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// discard drains and closes the body of a response that is thrown away.
+func discard(resp *http.Response) {
+	_, _ = io.CopyN(io.Discard, resp.Body, maxDrain)
+	resp.Body.Close()
+}
+
+// permanent marks an error DoRequest must not retry.
+type permanent struct {
+	error
+}
+
+func (permanent) Retryable() bool { return false }
+
+func (p permanent) Unwrap() error { return p.error }
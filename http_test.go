@@ -0,0 +1,239 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDoRequest(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		bodies = append(bodies, string(b))
+		if len(bodies) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := New(time.Hour, time.Hour)
+	resp, err := DoRequest(r, context.Background(), srv.Client(), req, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if b, err := io.ReadAll(resp.Body); err != nil || string(b) != "ok" {
+		t.Fatalf("read response body %q, %v; want %q", b, err, "ok")
+	}
+	if len(bodies) != 2 || bodies[0] != "payload" || bodies[1] != "payload" {
+		t.Fatalf("server received bodies %q, want the payload twice", bodies)
+	}
+}
+
+func TestDoRequest_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := New(time.Millisecond, time.Millisecond, Timeout(50*time.Millisecond))
+	start := time.Now()
+	_, err = DoRequest(r, context.Background(), srv.Client(), req, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("request outlived the timeout of the run by %v", took)
+	}
+}
+
+func TestDoRequest_NotRewindable(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPut, srv.URL, io.NopCloser(strings.NewReader("payload")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := New(0, 0)
+	_, err = DoRequest(r, context.Background(), srv.Client(), req, nil)
+	if !errors.Is(err, ErrBodyNotRewindable) {
+		t.Fatalf("got %v, want %v", err, ErrBodyNotRewindable)
+	}
+	if calls != 1 {
+		t.Fatalf("got %d requests, want 1", calls)
+	}
+}
+
+func TestDoRequest_Classify(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := New(0, 0, Attempts(3))
+	retryNotFound := func(resp *http.Response, err error) bool {
+		return err != nil || resp.StatusCode == http.StatusNotFound
+	}
+	if _, err := DoRequest(r, context.Background(), srv.Client(), req, retryNotFound); err == nil {
+		t.Fatalf("succeeded on a retried status")
+	}
+	if calls != 3 {
+		t.Fatalf("got %d requests, want 3", calls)
+	}
+
+	calls = 0
+	resp, err := DoRequest(New(0, 0), context.Background(), srv.Client(), req, nil)
+	if err != nil || resp.StatusCode != http.StatusNotFound || calls != 1 {
+		t.Fatalf("got %v after %d requests, want the not found response as is", err, calls)
+	}
+	resp.Body.Close()
+}
+
+func TestDoRequest_HeaderBackoff(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("X-RateLimit-Reset-Ms", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resetMs := func(h http.Header) (time.Duration, bool) {
+		ms, err := strconv.Atoi(h.Get("X-RateLimit-Reset-Ms"))
+		if err != nil {
+			return 0, false
+		}
+		return time.Duration(ms) * time.Millisecond, true
+	}
+	r := New(time.Hour, time.Hour, HeaderBackoff(resetMs))
+	tt := time.Now()
+	resp, err := DoRequest(r, context.Background(), srv.Client(), req, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if d := time.Since(tt); d < 30*time.Millisecond || d > time.Second {
+		t.Fatalf("retried after %v, want the 30ms from the header", d)
+	}
+	if r.slept != 30*time.Millisecond {
+		t.Fatalf("slept %v, want 30ms", r.slept)
+	}
+}
+
+// closeCounter counts the response bodies closed by the client of a test.
+type closeCounter struct {
+	http.RoundTripper
+	opened, closed int32
+}
+
+func (c *closeCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := c.RoundTripper.RoundTrip(req)
+	if err == nil {
+		atomic.AddInt32(&c.opened, 1)
+		resp.Body = countedBody{resp.Body, &c.closed}
+	}
+	return resp, err
+}
+
+type countedBody struct {
+	io.ReadCloser
+	closed *int32
+}
+
+func (b countedBody) Close() error {
+	atomic.AddInt32(b.closed, 1)
+	return b.ReadCloser.Close()
+}
+
+func TestDoRequest_DiscardKept(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		_, _ = io.WriteString(w, "slow")
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc := &closeCounter{RoundTripper: srv.Client().Transport}
+	client := &http.Client{Transport: cc}
+
+	r := New(0, 0, Attempts(3), SlowAttemptThreshold(time.Millisecond))
+	resp, err := DoRequest(r, context.Background(), client, req, nil)
+	if resp != nil || err != ErrSlowAttempt {
+		t.Fatalf("got %v, %v; want no response and %v", resp, err, ErrSlowAttempt)
+	}
+	if cc.opened != 3 || cc.closed != 3 {
+		t.Fatalf("closed %d of %d responses", cc.closed, cc.opened)
+	}
+}
+
+func TestDoRequest_AcceptLast(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := New(0, 0, Attempts(2), AcceptLastOnExhaustion(func(error) bool { return true }))
+	resp, err := DoRequest(r, context.Background(), srv.Client(), req, nil)
+	if resp != nil || err == nil {
+		t.Fatalf("got %v, %v; want no response and the error of the last attempt", resp, err)
+	}
+}
@@ -0,0 +1,44 @@
+package retry
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNilStore is returned by RunOnce when given a nil store.
+var ErrNilStore = errors.New("retry: nil idempotency store")
+
+// IdempotencyStore records which operations completed, typically durably,
+// so that they aren't repeated, even across process restarts.
+type IdempotencyStore interface {
+	// Seen reports whether the operation identified by key completed.
+	Seen(key string) bool
+
+	// Mark records that the operation identified by key completed.
+	Mark(key string)
+}
+
+// RunOnce runs fn under the policy of r unless store reports the operation
+// identified by key as completed. The store is consulted before every
+// attempt, an operation seen there counts as a success without executing fn
+// again, and a successful attempt is marked in it. This keeps retries of
+// non-idempotent operations from repeating them after a crash, as long as
+// the store is durable.
+func RunOnce(r *Retrier, ctx context.Context, store IdempotencyStore, key string, fn func() error) error {
+	if fn == nil {
+		return ErrNilFunc
+	}
+	if store == nil {
+		return ErrNilStore
+	}
+	return r.Run(ctx, func() error {
+		if store.Seen(key) {
+			return nil
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+		store.Mark(key)
+		return nil
+	})
+}
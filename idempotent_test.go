@@ -0,0 +1,51 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+type memoryStore map[string]bool
+
+func (s memoryStore) Seen(key string) bool { return s[key] }
+func (s memoryStore) Mark(key string)      { s[key] = true }
+
+func TestRunOnce(t *testing.T) {
+	ctx := context.Background()
+	store := memoryStore{}
+
+	var executions int
+	charge := func() error {
+		executions++
+		if executions == 1 {
+			return io.EOF
+		}
+		return nil
+	}
+
+	// The first process charges after a failed attempt, then crashes before
+	// it can report the result.
+	if err := RunOnce(New(0, 0), ctx, store, "order-42", charge); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if executions != 2 {
+		t.Fatalf("got %d executions, want 2", executions)
+	}
+
+	// The restarted process retries the operation from scratch.
+	if err := RunOnce(New(0, 0), ctx, store, "order-42", charge); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if executions != 2 {
+		t.Fatalf("operation executed again after resume")
+	}
+
+	if err := RunOnce(New(0, 0), ctx, store, "order-43", charge); err != nil || executions != 3 {
+		t.Fatalf("got %v after %d executions, want another key executed", err, executions)
+	}
+
+	if err := RunOnce(New(0, 0), ctx, nil, "order-44", charge); err != ErrNilStore {
+		t.Fatalf("got %v for a nil store, want %v", err, ErrNilStore)
+	}
+}
@@ -0,0 +1,173 @@
+package retry
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+)
+
+// SeededJitter derives the jitter of every delay from a hash of instanceID
+// and the attempt number instead of a random number generator. Runs of the
+// same instance jitter identically, while different instances are spread
+// apart.
+func SeededJitter(instanceID int64) Option {
+	return func(r *Retrier) {
+		r.instanceID = instanceID
+		r.seededJitter = true
+	}
+}
+
+// TaperingJitter shrinks the jitter as the attempts run out, so that early
+// delays spread freely while the final ones are punctual. The jitter before
+// the last attempt is zero. It only affects retriers with limited Attempts.
+func TaperingJitter() Option {
+	return func(r *Retrier) {
+		r.taperJitter = true
+	}
+}
+
+// HostnameJitter mixes a hash of the host name into the jitter seed when the
+// retrier is created, giving every host a stable jitter sequence of its own.
+// Pods started from the same image, and seeded alike, at the same moment then
+// don't retry in lockstep. It applies to both the random and the SeededJitter
+// sequences.
+func HostnameJitter() Option {
+	return func(r *Retrier) {
+		r.hostSalt = hostSalt()
+	}
+}
+
+// hostname returns the host name, replaced in tests.
+var hostname = os.Hostname
+
+// hostSalt returns a hash of the host name, or zero if it is unknown.
+func hostSalt() int64 {
+	name, err := hostname()
+	if err != nil {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(splitmix64(h.Sum64()))
+}
+
+// PooledRNG makes the retrier draw its jitter from generators borrowed from a
+// process-wide pool instead of owning one. Short-lived retriers created at a
+// high rate then neither allocate a generator each nor contend on a shared
+// one. Pooled jitter isn't reproducible, WithSeed doesn't apply to it.
+func PooledRNG() Option {
+	return func(r *Retrier) {
+		r.pooledRNG = true
+	}
+}
+
+// rngPool holds the generators borrowed by retriers with PooledRNG.
+var rngPool = sync.Pool{
+	New: func() any {
+		return rand.New(rand.NewSource(rand.Int63()))
+	},
+}
+
+// NoJitterOnLast makes the sleep before the last of limited Attempts use the
+// unjittered delay. Spreading that sleep wastes time, as no retry follows
+// the last attempt anyway.
+func NoJitterOnLast() Option {
+	return func(r *Retrier) {
+		r.noJitterOnLast = true
+	}
+}
+
+// StaggerStart delays the first attempt by a uniformly random duration in
+// [0, max], so that many processes started together don't hit a dependency at
+// once. It is drawn for the first Wait and again after Reset, independently
+// of Jitter; the delays between attempts aren't affected.
+func StaggerStart(max time.Duration) Option {
+	return func(r *Retrier) {
+		r.stagger = max
+	}
+}
+
+// RandFunc makes the retrier draw the random numbers of its jitter, stagger
+// and ceiling spread from f, which must return uniformly distributed numbers
+// in [0, 1). Normally distributed jitter is derived from pairs of them. It is a
+// lighter alternative to WithSeed for scripting exact delays in tests or using
+// a custom source of entropy, and takes precedence over SeededJitter and
+// PooledRNG.
+func RandFunc(f func() float64) Option {
+	return func(r *Retrier) {
+		r.randFunc = f
+	}
+}
+
+// jitterScale returns the factor the configured jitter is scaled by.
+func (r *Retrier) jitterScale() float64 {
+	// r.Attempts still includes the attempt being waited for.
+	if r.noJitterOnLast && r.Attempts == 1 {
+		return 0
+	}
+	if !r.taperJitter || r.Attempts < 0 || r.startAttempts <= 1 {
+		return 1
+	}
+	left := float64(r.Attempts - 1)
+	if left < 0 {
+		left = 0
+	}
+	return left / float64(r.startAttempts-1)
+}
+
+// normFloat64 returns a standard normally distributed number for jitter.
+func (r *Retrier) normFloat64() float64 {
+	if r.randFunc != nil {
+		return boxMuller(r.randFunc(), r.randFunc())
+	}
+	if r.seededJitter {
+		h := splitmix64(uint64(r.instanceID^r.hostSalt) ^ splitmix64(uint64(r.attempt)))
+		return boxMuller(unitFloat(h), unitFloat(splitmix64(h)))
+	}
+	if r.pooledRNG {
+		g := rngPool.Get().(*rand.Rand)
+		f := g.NormFloat64()
+		rngPool.Put(g)
+		return f
+	}
+	return r.rng().NormFloat64()
+}
+
+// uniform returns a uniformly distributed number in [0, 1).
+func (r *Retrier) uniform() float64 {
+	if r.randFunc != nil {
+		return r.randFunc()
+	}
+	if r.pooledRNG {
+		g := rngPool.Get().(*rand.Rand)
+		f := g.Float64()
+		rngPool.Put(g)
+		return f
+	}
+	return r.rng().Float64()
+}
+
+// splitmix64 is the finalizer of the SplitMix64 generator, a fast hash with
+// good avalanche properties.
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// unitFloat maps x to [0, 1).
+func unitFloat(x uint64) float64 {
+	return float64(x>>11) / (1 << 53)
+}
+
+// boxMuller transforms two uniform numbers in [0, 1) into a standard normally
+// distributed one.
+func boxMuller(u1, u2 float64) float64 {
+	// Avoid log(0).
+	u1 = 1 - u1
+	return math.Sqrt(-2*math.Log(u1)) * math.Cos(2*math.Pi*u2)
+}
@@ -0,0 +1,248 @@
+package retry
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSeededJitter(t *testing.T) {
+	jitters := func(id int64) []time.Duration {
+		r := New(time.Microsecond, time.Hour, Jitter(0.5), SeededJitter(id))
+		var ds []time.Duration
+		for i := 0; i < 10; i++ {
+			r.attempt = i
+			ds = append(ds, r.applyJitter(time.Second))
+		}
+		return ds
+	}
+
+	a, b := jitters(1), jitters(1)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("attempt %d: %v != %v for the same instance", i, a[i], b[i])
+		}
+	}
+
+	// Across instances the first delay should be spread around its mean.
+	var below, above int
+	for id := int64(0); id < 1000; id++ {
+		switch d := jitters(id)[0]; {
+		case d < time.Second:
+			below++
+		case d > time.Second:
+			above++
+		}
+	}
+	if below < 400 || above < 400 {
+		t.Fatalf("jitter not spread across instances: %d below, %d above", below, above)
+	}
+
+	distinct := make(map[time.Duration]bool)
+	for id := int64(0); id < 100; id++ {
+		distinct[jitters(id)[3]] = true
+	}
+	if len(distinct) < 95 {
+		t.Fatalf("only %d distinct jitters across 100 instances", len(distinct))
+	}
+}
+
+func TestTaperingJitter(t *testing.T) {
+	const attempts = 5
+
+	// spread returns the standard deviation of the jitter before each attempt
+	// across many runs.
+	spread := func() []float64 {
+		samples := make([][]float64, attempts)
+		for i := 0; i < 500; i++ {
+			r := New(time.Millisecond, time.Hour, Rate(1), Jitter(0.2), Attempts(attempts), TaperingJitter())
+			r.startAttempts = attempts
+			r.attempt = 1
+			for a := 0; a < attempts; a++ {
+				r.Delay = time.Second
+				samples[a] = append(samples[a], r.next().Seconds())
+				r.Attempts--
+			}
+		}
+		var stds []float64
+		for _, s := range samples {
+			stds = append(stds, stdDev(s))
+		}
+		return stds
+	}
+
+	stds := spread()
+	for i := 1; i < len(stds); i++ {
+		if stds[i] >= stds[i-1] {
+			t.Fatalf("jitter did not shrink: %v", stds)
+		}
+	}
+	if stds[len(stds)-1] != 0 {
+		t.Fatalf("last delay jittered: %v", stds)
+	}
+}
+
+func TestPooledRNG(t *testing.T) {
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			seen := make(map[time.Duration]bool)
+			for i := 0; i < 100; i++ {
+				r := New(time.Millisecond, time.Hour, Jitter(0.5), PooledRNG())
+				r.Delay = time.Second
+				seen[r.applyJitter(r.Delay)] = true
+			}
+			if len(seen) < 90 {
+				t.Errorf("got %d distinct jittered delays out of 100", len(seen))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkJitterParallel(b *testing.B) {
+	b.Run("Global", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_ = rand.NormFloat64()
+			}
+		})
+	})
+	for _, bm := range []struct {
+		name string
+		opts []Option
+	}{
+		{"Owned", nil},
+		{"Pooled", []Option{PooledRNG()}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			opts := append([]Option{Jitter(0.1)}, bm.opts...)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					// A fresh retrier per operation, like one per request.
+					r := New(time.Millisecond, time.Second, opts...)
+					_ = r.normFloat64()
+				}
+			})
+		})
+	}
+}
+
+func TestNoJitterOnLast(t *testing.T) {
+	var sleeps []time.Duration
+	schedule := func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	r := New(10*time.Millisecond, time.Hour, Rate(1), Jitter(0.5), Attempts(5),
+		WithSeed(1), WithScheduler(schedule), NoJitterOnLast())
+
+	var prev time.Duration
+	for r.Wait(context.Background()) {
+		if len(sleeps) == 5 && sleeps[4] != prev {
+			t.Fatalf("got final sleep %v, want unjittered %v", sleeps[4], prev)
+		}
+		prev = r.Delay
+	}
+	if len(sleeps) != 5 {
+		t.Fatalf("got %d sleeps, want 5", len(sleeps))
+	}
+
+	jittered := 0
+	for _, d := range sleeps[1:4] {
+		if d != 10*time.Millisecond {
+			jittered++
+		}
+	}
+	if jittered == 0 {
+		t.Fatalf("earlier sleeps weren't jittered: %v", sleeps)
+	}
+}
+
+func TestHostnameJitter(t *testing.T) {
+	defer func(orig func() (string, error)) { hostname = orig }(hostname)
+
+	jitters := func(host string, opts ...Option) []time.Duration {
+		hostname = func() (string, error) { return host, nil }
+		opts = append([]Option{Jitter(0.5), HostnameJitter()}, opts...)
+		r := New(time.Millisecond, time.Hour, opts...)
+		var ds []time.Duration
+		for i := 0; i < 10; i++ {
+			r.Delay = time.Second
+			ds = append(ds, r.applyJitter(r.Delay))
+			r.attempt++
+		}
+		return ds
+	}
+	equal := func(a, b []time.Duration) bool {
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, opt := range []Option{WithSeed(1), SeededJitter(1)} {
+		a, b := jitters("pod-a", opt), jitters("pod-b", opt)
+		if equal(a, b) {
+			t.Fatalf("different hosts jitter alike: %v", a)
+		}
+		if !equal(a, jitters("pod-a", opt)) {
+			t.Fatalf("same host jitters differently")
+		}
+	}
+}
+
+func TestRandFunc(t *testing.T) {
+	ctx := context.Background()
+
+	u1 := 1 - math.Exp(-0.5)
+	seq := []float64{
+		0, 0, // jitter of the immediate first delay
+		0.5,    // stagger
+		0, 0.5, // jitter of 2s: none
+		u1, 0.5, // jitter of 4s: about -1 standard deviation
+		0, 0, // computed by the Wait that finds no attempts left
+	}
+	var i int
+	next := func() float64 {
+		f := seq[i]
+		i++
+		return f
+	}
+
+	var requested []time.Duration
+	schedule := func(ctx context.Context, d time.Duration) error {
+		requested = append(requested, d)
+		return nil
+	}
+	r := New(time.Second, time.Hour, Rate(2), Jitter(0.5), Attempts(3), StaggerStart(10*time.Second), RandFunc(next), WithScheduler(schedule))
+	for r.Wait(ctx) {
+	}
+
+	want := []time.Duration{
+		5 * time.Second,
+		2 * time.Second,
+		toDuration(boxMuller(u1, 0.5)*0.5*float64(4*time.Second) + float64(4*time.Second)),
+	}
+	if len(requested) != len(want) {
+		t.Fatalf("got %v, want %v", requested, want)
+	}
+	for k := range want {
+		if requested[k] != want[k] {
+			t.Fatalf("got %v, want %v", requested, want)
+		}
+	}
+	if d := want[2]; d < 1999*time.Millisecond || d > 2001*time.Millisecond {
+		t.Fatalf("jittered 4s to %v, want about 2s", d)
+	}
+	if i != len(seq) {
+		t.Fatalf("drew %d numbers, want %d", i, len(seq))
+	}
+}
@@ -0,0 +1,98 @@
+package retry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Metrics accumulates the retry metrics of a request. It is safe for
+// concurrent use.
+type Metrics struct {
+	mu       sync.Mutex
+	runs     int
+	attempts int
+	backoff  time.Duration
+}
+
+// Runs returns the number of recorded runs.
+func (m *Metrics) Runs() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.runs
+}
+
+// Attempts returns the number of attempts made by the recorded runs.
+func (m *Metrics) Attempts() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.attempts
+}
+
+// Retries returns the number of attempts beyond the first of each run.
+func (m *Metrics) Retries() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.attempts < m.runs {
+		return 0
+	}
+	return m.attempts - m.runs
+}
+
+// Backoff returns the time the recorded runs spent outside of attempts.
+func (m *Metrics) Backoff() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.backoff
+}
+
+func (m *Metrics) record(attempts int, backoff time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.runs++
+	m.attempts += attempts
+	m.backoff += backoff
+}
+
+type metricsKey struct{}
+
+// ContextWithMetrics returns a context carrying m, for DoMetered to record
+// into.
+func ContextWithMetrics(ctx context.Context, m *Metrics) context.Context {
+	return context.WithValue(ctx, metricsKey{}, m)
+}
+
+// MetricsFromContext returns the metrics carried by ctx, or nil.
+func MetricsFromContext(ctx context.Context) *Metrics {
+	m, _ := ctx.Value(metricsKey{}).(*Metrics)
+	return m
+}
+
+// DoMetered is like Do, but records the number of attempts and the time spent
+// backing off into the metrics carried by ctx, if any.
+func DoMetered[T any](r *Retrier, ctx context.Context, fn func() (T, error)) (T, error) {
+	m := MetricsFromContext(ctx)
+	if m == nil || fn == nil {
+		return Do(r, ctx, fn)
+	}
+
+	var (
+		attempts int
+		exec     time.Duration
+	)
+	start := r.now()
+	v, err := Do(r, ctx, func() (T, error) {
+		attempts++
+		t := r.now()
+		defer func() { exec += r.since(t) }()
+		return fn()
+	})
+	m.record(attempts, r.since(start)-exec)
+
+	return v, err
+}
@@ -0,0 +1,46 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestDoMetered(t *testing.T) {
+	var m Metrics
+	ctx := ContextWithMetrics(context.Background(), &m)
+
+	r := New(5*time.Millisecond, 5*time.Millisecond)
+
+	var calls int
+	v, err := DoMetered(r, ctx, func() (string, error) {
+		calls++
+		if calls < 3 {
+			return "", io.EOF
+		}
+		return "ok", nil
+	})
+	if v != "ok" || err != nil {
+		t.Fatalf("DoMetered = %q, %v", v, err)
+	}
+
+	if m.Runs() != 1 || m.Attempts() != 3 || m.Retries() != 2 {
+		t.Fatalf("runs %d, attempts %d, retries %d; want 1, 3, 2", m.Runs(), m.Attempts(), m.Retries())
+	}
+	if b := m.Backoff(); b < 10*time.Millisecond {
+		t.Fatalf("backoff %v, want at least 10ms", b)
+	}
+}
+
+func TestDoMetered_NoCollector(t *testing.T) {
+	ctx := context.Background()
+	if MetricsFromContext(ctx) != nil {
+		t.Fatalf("metrics found in empty context")
+	}
+
+	r := New(0, 0)
+	if _, err := DoMetered(r, ctx, func() (int, error) { return 1, nil }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package retry
+
+import "time"
+
+// Nested returns a clone of inner whose Timeout is bounded by the time left
+// until the deadline of the ongoing Run of outer, so that retries nested in an
+// attempt of outer can't overrun it. Call it at the start of every attempt of
+// outer:
+//
+//	outer.Run(ctx, func() error {
+//		return retry.Nested(outer, inner).Run(ctx, subOperation)
+//	})
+//
+// If outer isn't running or its run has no deadline, the clone keeps the
+// Timeout of inner.
+func Nested(outer, inner *Retrier) *Retrier {
+	c := inner.Clone()
+	if outer.deadline.IsZero() {
+		return c
+	}
+
+	left := time.Until(outer.deadline)
+	if left <= 0 {
+		// The outer run is already due, leave no time at all.
+		left = time.Nanosecond
+	}
+	if c.Timeout <= 0 || left < c.Timeout {
+		c.Timeout = left
+	}
+	return c
+}
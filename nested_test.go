@@ -0,0 +1,45 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestNested(t *testing.T) {
+	ctx := context.Background()
+
+	outer := New(10*time.Millisecond, 10*time.Millisecond, Timeout(100*time.Millisecond))
+	inner := New(time.Millisecond, time.Millisecond, Attempts(3), Timeout(time.Hour))
+
+	var budgets []time.Duration
+	err := outer.Run(ctx, func() error {
+		in := Nested(outer, inner)
+		budgets = append(budgets, in.Timeout)
+		return in.Run(ctx, func() error { return io.EOF })
+	})
+	if !errors.Is(err, io.EOF) && !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(budgets) < 2 {
+		t.Fatalf("got %d outer attempts, want several", len(budgets))
+	}
+	if budgets[0] > 100*time.Millisecond {
+		t.Fatalf("inner budget %v exceeds the outer timeout", budgets[0])
+	}
+	for i := 1; i < len(budgets); i++ {
+		if budgets[i] >= budgets[i-1] {
+			t.Fatalf("inner budget didn't shrink: %v", budgets)
+		}
+	}
+
+	if in := Nested(outer, inner); in.Timeout != time.Hour {
+		t.Fatalf("got timeout %v outside an outer run, want %v", in.Timeout, time.Hour)
+	}
+	if inner.Timeout != time.Hour {
+		t.Fatalf("Nested changed the inner retrier")
+	}
+}
@@ -0,0 +1,129 @@
+package retry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// PartitionedRetrier keeps independent backoff state per key, e.g. per
+// tenant, so that failures of one key don't slow down the retries of another.
+// It is safe for concurrent use. Waits for the same key are serialized.
+type PartitionedRetrier struct {
+	template *Retrier
+	idle     time.Duration
+
+	mu        sync.Mutex
+	parts     map[string]*partition
+	lastSweep time.Time
+}
+
+type partition struct {
+	mu   sync.Mutex
+	r    *Retrier
+	last time.Time
+	refs int
+	// stale marks a partition reset while in use, to be reset by its next
+	// Wait.
+	stale bool
+}
+
+// NewPartitioned creates a partitioned retrier whose per key retriers are
+// clones of template. Keys that were not waited on for idle are evicted,
+// forgetting their backoff. Keys are never evicted when idle is zero.
+func NewPartitioned(template *Retrier, idle time.Duration) *PartitionedRetrier {
+	return &PartitionedRetrier{
+		template: template.Clone(),
+		idle:     idle,
+		parts:    make(map[string]*partition),
+	}
+}
+
+// Wait is like Retrier.Wait using the backoff state of key.
+func (p *PartitionedRetrier) Wait(ctx context.Context, key string) bool {
+	part := p.acquire(key)
+	defer p.release(part)
+
+	part.mu.Lock()
+	defer part.mu.Unlock()
+
+	if p.takeStale(part) {
+		part.r.Reset()
+	}
+	return part.r.Wait(ctx)
+}
+
+// Reset resets the backoff state of key. A Wait for key in progress isn't
+// affected, the next one starts over.
+func (p *PartitionedRetrier) Reset(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	part, ok := p.parts[key]
+	if !ok {
+		return
+	}
+	if part.refs == 0 {
+		delete(p.parts, key)
+		return
+	}
+	// Keep the partition so that Waits for key stay serialized.
+	part.stale = true
+}
+
+// takeStale reports whether part was reset while in use and clears the mark.
+func (p *PartitionedRetrier) takeStale(part *partition) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stale := part.stale
+	part.stale = false
+	return stale
+}
+
+// Len returns the number of keys with backoff state.
+func (p *PartitionedRetrier) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.parts)
+}
+
+func (p *PartitionedRetrier) acquire(key string) *partition {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.template.now()
+	p.sweep(now)
+
+	part, ok := p.parts[key]
+	if !ok {
+		part = &partition{r: p.template.Clone()}
+		p.parts[key] = part
+	}
+	part.refs++
+	part.last = now
+	return part
+}
+
+func (p *PartitionedRetrier) release(part *partition) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	part.refs--
+	part.last = p.template.now()
+}
+
+// sweep evicts idle keys. It runs at most once per idle period.
+func (p *PartitionedRetrier) sweep(now time.Time) {
+	if p.idle <= 0 || now.Sub(p.lastSweep) < p.idle {
+		return
+	}
+	p.lastSweep = now
+
+	for key, part := range p.parts {
+		if part.refs == 0 && now.Sub(part.last) >= p.idle {
+			delete(p.parts, key)
+		}
+	}
+}
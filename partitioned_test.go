@@ -0,0 +1,114 @@
+package retry
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPartitionedRetrier(t *testing.T) {
+	ctx := context.Background()
+
+	p := NewPartitioned(New(time.Millisecond, time.Second, Rate(2)), 0)
+
+	for i := 0; i < 4; i++ {
+		p.Wait(ctx, "a")
+	}
+	p.Wait(ctx, "b")
+
+	a := p.parts["a"].r.Delay
+	b := p.parts["b"].r.Delay
+	if a != 8*time.Millisecond {
+		t.Fatalf("delay of a = %v, want 8ms", a)
+	}
+	if b != time.Millisecond {
+		t.Fatalf("delay of b = %v, want 1ms", b)
+	}
+
+	p.Reset("a")
+	p.Wait(ctx, "a")
+	if d := p.parts["a"].r.Delay; d != time.Millisecond {
+		t.Fatalf("delay of a after Reset = %v, want 1ms", d)
+	}
+}
+
+func TestPartitionedRetrier_Evict(t *testing.T) {
+	ctx := context.Background()
+
+	p := NewPartitioned(New(0, 0), 10*time.Millisecond)
+	p.Wait(ctx, "a")
+	p.Wait(ctx, "b")
+	if p.Len() != 2 {
+		t.Fatalf("len = %d, want 2", p.Len())
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	p.Wait(ctx, "c")
+	if p.Len() != 1 {
+		t.Fatalf("idle keys not evicted: len = %d", p.Len())
+	}
+}
+
+func TestPartitionedRetrier_Concurrent(t *testing.T) {
+	ctx := context.Background()
+
+	p := NewPartitioned(New(0, 0), time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		key := string(rune('a' + i%3))
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				p.Wait(ctx, key)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestPartitionedRetrier_ResetWhileWaiting(t *testing.T) {
+	ctx := context.Background()
+
+	var inFlight, overlaps int32
+	schedule := func(ctx context.Context, d time.Duration) error {
+		if atomic.AddInt32(&inFlight, 1) > 1 {
+			atomic.AddInt32(&overlaps, 1)
+		}
+		time.Sleep(100 * time.Microsecond)
+		atomic.AddInt32(&inFlight, -1)
+		return nil
+	}
+	p := NewPartitioned(New(time.Millisecond, time.Second, WithScheduler(schedule)), 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				p.Wait(ctx, "a")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				p.Reset("a")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&overlaps); n != 0 {
+		t.Fatalf("Waits for the same key overlapped %d times", n)
+	}
+
+	p.Reset("a")
+	p.Wait(ctx, "a")
+	if d := p.parts["a"].r.Delay; d != time.Millisecond {
+		t.Fatalf("delay after Reset = %v, want 1ms", d)
+	}
+}
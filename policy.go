@@ -0,0 +1,147 @@
+package retry
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy parses a compact retry policy, e.g. from a config file, into an
+// option setting all of it at once. A spec is a list of fields separated by
+// slashes, each appearing at most once and in any order:
+//
+//	<n>x             Attempts, e.g. 5x.
+//	exp | exp=<rate> Exponential growth, by the default rate or by rate.
+//	fixed            No growth, like Rate(1).
+//	<floor>..<ceil>  Floor and Ceil, as time.ParseDuration durations.
+//	<delay>          Floor and Ceil both set to delay.
+//	jitter=<j>       Jitter.
+//	timeout=<d>      Timeout of Run.
+//
+// For example "5x/exp/100ms..10s/jitter=0.1" makes 5 attempts backing off
+// exponentially from 100ms up to 10s with 10% jitter. Fields left out keep
+// the values set by New and earlier options.
+func Policy(spec string) (Option, error) {
+	var (
+		opts []Option
+		seen = make(map[string]bool)
+	)
+	once := func(kind, field string) error {
+		if seen[kind] {
+			return fmt.Errorf("retry: policy %q: field %q: duplicate %s", spec, field, kind)
+		}
+		seen[kind] = true
+		return nil
+	}
+
+	for _, field := range strings.Split(spec, "/") {
+		field = strings.TrimSpace(field)
+		key, value, hasValue := strings.Cut(field, "=")
+
+		var (
+			kind string
+			opt  Option
+			err  error
+		)
+		switch {
+		case field == "":
+			return nil, fmt.Errorf("retry: policy %q: empty field", spec)
+		case key == "exp" || key == "fixed":
+			kind = "growth"
+			opt, err = parseGrowth(key, value, hasValue)
+		case key == "jitter" && hasValue:
+			kind = "jitter"
+			var j float64
+			if j, err = strconv.ParseFloat(value, 64); err == nil && (j < 0 || math.IsNaN(j) || math.IsInf(j, 0)) {
+				err = fmt.Errorf("jitter %v out of range", j)
+			}
+			opt = Jitter(j)
+		case key == "timeout" && hasValue:
+			kind = "timeout"
+			var d time.Duration
+			if d, err = parseDelay(value); err == nil && d == 0 {
+				err = fmt.Errorf("zero timeout")
+			}
+			opt = Timeout(d)
+		case hasValue:
+			return nil, fmt.Errorf("retry: policy %q: unknown field %q", spec, field)
+		case strings.HasSuffix(field, "x"):
+			kind = "attempts"
+			var n int
+			if n, err = strconv.Atoi(strings.TrimSuffix(field, "x")); err == nil && n <= 0 {
+				err = fmt.Errorf("%d attempts", n)
+			}
+			opt = Attempts(n)
+		default:
+			kind = "delays"
+			opt, err = parseDelays(field)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("retry: policy %q: field %q: %w", spec, field, err)
+		}
+		if err := once(kind, field); err != nil {
+			return nil, err
+		}
+		opts = append(opts, opt)
+	}
+
+	return func(r *Retrier) {
+		for _, opt := range opts {
+			opt(r)
+		}
+	}, nil
+}
+
+func parseGrowth(key, value string, hasValue bool) (Option, error) {
+	switch {
+	case key == "fixed" && !hasValue:
+		return Rate(1), nil
+	case key == "fixed":
+		return nil, fmt.Errorf("fixed takes no value")
+	case !hasValue:
+		return Rate(math.Phi), nil
+	}
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	if math.IsNaN(rate) || rate < 1 || math.IsInf(rate, 0) {
+		return nil, fmt.Errorf("rate %v out of range", rate)
+	}
+	return Rate(rate), nil
+}
+
+func parseDelays(field string) (Option, error) {
+	lo, hi, ok := strings.Cut(field, "..")
+	if !ok {
+		hi = lo
+	}
+	floor, err := parseDelay(lo)
+	if err != nil {
+		return nil, err
+	}
+	ceil, err := parseDelay(hi)
+	if err != nil {
+		return nil, err
+	}
+	if ceil < floor {
+		return nil, fmt.Errorf("ceil %v below floor %v", ceil, floor)
+	}
+	return func(r *Retrier) {
+		r.Floor = floor
+		r.Ceil = ceil
+	}, nil
+}
+
+func parseDelay(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative duration %v", d)
+	}
+	return d, nil
+}
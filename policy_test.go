@@ -0,0 +1,56 @@
+package retry
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPolicy(t *testing.T) {
+	for _, tt := range []struct {
+		spec string
+		want Retrier
+	}{
+		{"5x/exp/100ms..10s/jitter=0.1", Retrier{Attempts: 5, Rate: math.Phi, Floor: 100 * time.Millisecond, Ceil: 10 * time.Second, Jitter: 0.1}},
+		{"exp=2/1s..1m", Retrier{Attempts: -1, Rate: 2, Floor: time.Second, Ceil: time.Minute}},
+		{"fixed/ 250ms /3x/timeout=5s", Retrier{Attempts: 3, Rate: 1, Floor: 250 * time.Millisecond, Ceil: 250 * time.Millisecond, Timeout: 5 * time.Second}},
+		{"jitter=0.5", Retrier{Attempts: -1, Rate: math.Phi, Floor: time.Millisecond, Ceil: time.Second, Jitter: 0.5}},
+	} {
+		opt, err := Policy(tt.spec)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.spec, err)
+		}
+		r := New(time.Millisecond, time.Second, opt)
+		if r.Attempts != tt.want.Attempts || r.Rate != tt.want.Rate || r.Floor != tt.want.Floor ||
+			r.Ceil != tt.want.Ceil || r.Jitter != tt.want.Jitter || r.Timeout != tt.want.Timeout {
+			t.Fatalf("%q: got attempts %d, rate %v, floor %v, ceil %v, jitter %v, timeout %v",
+				tt.spec, r.Attempts, r.Rate, r.Floor, r.Ceil, r.Jitter, r.Timeout)
+		}
+	}
+}
+
+func TestPolicy_Malformed(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"5x//exp",
+		"0x",
+		"fivex",
+		"exp/fixed",
+		"exp=0.5",
+		"exp=NaN",
+		"fixed=2",
+		"10s..1s",
+		"-1s",
+		"100ms..",
+		"jitter=-1",
+		"jitter",
+		"timeout=0s",
+		"3x/4x",
+		"retries=3",
+		"slow",
+	} {
+		if _, err := Policy(spec); err == nil {
+			t.Fatalf("%q: parsed a malformed spec", spec)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package retry
+
+import (
+	"sync"
+	"time"
+)
+
+// AttemptRecord summarizes an attempt made by Run.
+type AttemptRecord struct {
+	// At is when the attempt started.
+	At time.Time
+
+	// Delay is the backoff slept before the attempt.
+	Delay time.Duration
+
+	// Err is the message of the error the attempt failed with, empty on
+	// success.
+	Err string
+}
+
+// KeepRecent makes Run remember its last n attempts across runs, so that a
+// crash handler or a debug endpoint can dump what the retrier was doing
+// lately with RecentAttempts. Only error messages are kept, the memory it
+// takes is fixed.
+func KeepRecent(n int) Option {
+	return func(r *Retrier) {
+		if n <= 0 {
+			r.recent = nil
+			return
+		}
+		r.recent = &attemptRing{buf: make([]AttemptRecord, 0, n)}
+	}
+}
+
+// RecentAttempts returns the attempts remembered with KeepRecent, oldest
+// first. Unlike the other accessors it is safe to call while Run is in
+// progress in another goroutine.
+func (r *Retrier) RecentAttempts() []AttemptRecord {
+	return r.recent.records()
+}
+
+// attemptRing is a fixed-size ring buffer of attempts.
+type attemptRing struct {
+	mu  sync.Mutex
+	buf []AttemptRecord
+	pos int
+}
+
+func (a *attemptRing) add(rec AttemptRecord) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.buf) < cap(a.buf) {
+		a.buf = append(a.buf, rec)
+		return
+	}
+	a.buf[a.pos] = rec
+	a.pos = (a.pos + 1) % len(a.buf)
+}
+
+func (a *attemptRing) records() []AttemptRecord {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	recs := make([]AttemptRecord, 0, len(a.buf))
+	recs = append(recs, a.buf[a.pos:]...)
+	return append(recs, a.buf[:a.pos]...)
+}
+
+// clone returns a ring of the same size, holding the same records if keep is
+// set.
+func (a *attemptRing) clone(keep bool) *attemptRing {
+	if a == nil {
+		return nil
+	}
+	c := &attemptRing{buf: make([]AttemptRecord, 0, cap(a.buf))}
+	if keep {
+		c.buf = append(c.buf, a.records()...)
+	}
+	return c
+}
+
+const (
+	// rateInterval is the resolution of EffectiveRate.
+	rateInterval = 10 * time.Millisecond
+	// maxRateIntervals bounds the intervals with attempts EffectiveRate
+	// keeps.
+	maxRateIntervals = 1024
+)
+
+// EffectiveRate returns the rate, in attempts per second, at which Run made
+// attempts over the last window, e.g. to detect retries amplifying the load
+// on a backend and shed load. Attempts are counted per 10ms interval, which
+// bounds the error, and the last 1024 intervals with attempts are kept, so
+// windows of up to 10 seconds are covered at any rate. It is safe to call
+// while Run is in progress in another goroutine.
+func (r *Retrier) EffectiveRate(window time.Duration) float64 {
+	if window <= 0 {
+		return 0
+	}
+	n := r.rate.count(r.now().Add(-window))
+	return float64(n) / window.Seconds()
+}
+
+// rateWindow counts attempts per interval of rateInterval since base, in a
+// ring buffer of the intervals with attempts grown on demand.
+type rateWindow struct {
+	mu        sync.Mutex
+	base      time.Time
+	intervals []rateCount
+	pos       int
+}
+
+type rateCount struct {
+	interval int64
+	n        int
+}
+
+func newRateWindow() *rateWindow {
+	return &rateWindow{}
+}
+
+// interval returns the number of the interval t falls into.
+func (w *rateWindow) interval(t time.Time) int64 {
+	d := t.Sub(w.base)
+	i := int64(d / rateInterval)
+	if d < 0 && d%rateInterval != 0 {
+		i--
+	}
+	return i
+}
+
+func (w *rateWindow) add(t time.Time) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.intervals) == 0 {
+		w.base = t
+	}
+	i := w.interval(t)
+	if n := len(w.intervals); n > 0 {
+		// The latest interval is the one before pos, also while growing.
+		last := &w.intervals[(w.pos+n-1)%n]
+		if last.interval == i {
+			last.n++
+			return
+		}
+	}
+
+	if len(w.intervals) < maxRateIntervals {
+		w.intervals = append(w.intervals, rateCount{interval: i, n: 1})
+		return
+	}
+	w.intervals[w.pos] = rateCount{interval: i, n: 1}
+	w.pos = (w.pos + 1) % len(w.intervals)
+}
+
+// count returns the number of attempts in the intervals ending after since.
+func (w *rateWindow) count(since time.Time) int {
+	if w == nil {
+		return 0
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.intervals) == 0 {
+		return 0
+	}
+	from := w.interval(since)
+	var n int
+	for _, c := range w.intervals {
+		if c.interval >= from {
+			n += c.n
+		}
+	}
+	return n
+}
@@ -0,0 +1,91 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRecentAttempts(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(0, 0, Attempts(7), KeepRecent(3))
+	if got := r.RecentAttempts(); len(got) != 0 {
+		t.Fatalf("got %d records before any run", len(got))
+	}
+
+	var calls int
+	_ = r.Run(ctx, func() error {
+		calls++
+		if calls == 7 {
+			return nil
+		}
+		return fmt.Errorf("attempt %d", calls)
+	})
+
+	got := r.RecentAttempts()
+	want := []string{"attempt 5", "attempt 6", ""}
+	if len(got) != len(want) {
+		t.Fatalf("got %d records, want %d", len(got), len(want))
+	}
+	for i, rec := range got {
+		if rec.Err != want[i] {
+			t.Fatalf("record %d: got %q, want %q", i, rec.Err, want[i])
+		}
+		if i > 0 && rec.At.Before(got[i-1].At) {
+			t.Fatalf("records out of order: %+v", got)
+		}
+	}
+
+	if got := r.Clone().RecentAttempts(); len(got) != 0 {
+		t.Fatalf("clone inherited %d records", len(got))
+	}
+	if got := New(0, 0).RecentAttempts(); got != nil {
+		t.Fatalf("got records without KeepRecent: %+v", got)
+	}
+}
+
+func TestEffectiveRate(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+
+	r := New(0, 0, Attempts(20), WithClock(clock))
+	if rate := r.EffectiveRate(time.Second); rate != 0 {
+		t.Fatalf("got rate %v before any attempt", rate)
+	}
+
+	// An attempt every 10ms, i.e. at 100 attempts per second.
+	_ = r.Run(context.Background(), func() error {
+		now = now.Add(10 * time.Millisecond)
+		return io.EOF
+	})
+
+	if rate := r.EffectiveRate(100 * time.Millisecond); rate < 80 || rate > 110 {
+		t.Fatalf("got rate %v, want about 100", rate)
+	}
+	// The window covers the whole run of 20 attempts.
+	if rate := r.EffectiveRate(time.Second); rate != 20 {
+		t.Fatalf("got rate %v over a second, want 20", rate)
+	}
+}
+
+func TestEffectiveRate_HighRate(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+
+	// 5000 attempts per second for two seconds.
+	r := New(0, 0, Attempts(10000), WithClock(clock))
+	_ = r.Run(context.Background(), func() error {
+		now = now.Add(200 * time.Microsecond)
+		return io.EOF
+	})
+
+	if rate := r.EffectiveRate(time.Second); rate < 4900 || rate > 5100 {
+		t.Fatalf("got rate %v, want about 5000", rate)
+	}
+	if rate := r.EffectiveRate(10 * time.Second); rate != 1000 {
+		t.Fatalf("got rate %v over 10s, want all 10000 attempts", rate)
+	}
+}
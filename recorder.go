@@ -0,0 +1,126 @@
+package retry
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// RecordedAttempt is an attempt of a recorded session.
+type RecordedAttempt struct {
+	// Attempt is the number of the attempt, starting at 1.
+	Attempt int `json:"attempt"`
+
+	// Delay is the delay slept before the attempt.
+	Delay time.Duration `json:"delay"`
+
+	// Err is the message of the error returned by the attempt, empty on
+	// success.
+	Err string `json:"error,omitempty"`
+}
+
+// Session is a recorded run. It can be serialized to JSON.
+type Session struct {
+	Attempts []RecordedAttempt `json:"attempts"`
+
+	// Success reports whether the run succeeded.
+	Success bool `json:"success"`
+
+	// Err is the message of the error returned by the run, empty on success.
+	Err string `json:"error,omitempty"`
+}
+
+// Recorder captures the most recent Run of the retriers it is attached to.
+// It is safe for concurrent use: retriers sharing a Recorder build their
+// sessions apart, and the one finishing last is kept.
+type Recorder struct {
+	mu      sync.Mutex
+	session Session
+}
+
+// WithRecorder makes Run record its session into rec. With MaxRetained, only
+// the last attempts of a session are kept.
+func WithRecorder(rec *Recorder) Option {
+	return func(r *Retrier) {
+		r.recorder = rec
+	}
+}
+
+// Session returns the recorded session, that of the run that finished last.
+func (rec *Recorder) Session() Session {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	return rec.session
+}
+
+// start begins the session s of a run.
+func (rec *Recorder) start(s *Session) {
+	if rec == nil {
+		return
+	}
+	*s = Session{}
+}
+
+// attempt adds attempt n to s, keeping only the last max attempts if max is
+// positive.
+func (rec *Recorder) attempt(s *Session, max, n int, delay time.Duration, err error) {
+	if rec == nil {
+		return
+	}
+	a := RecordedAttempt{Attempt: n, Delay: delay}
+	if err != nil {
+		a.Err = err.Error()
+	}
+	s.Attempts = retain(append(s.Attempts, a), max)
+}
+
+// finish completes the session s and makes it the recorded one.
+func (rec *Recorder) finish(s *Session, err error) {
+	if rec == nil {
+		return
+	}
+	s.Success = err == nil
+	if err != nil {
+		s.Err = err.Error()
+	}
+
+	rec.mu.Lock()
+	rec.session = *s
+	rec.mu.Unlock()
+}
+
+// Replay returns a retrier and a function reproducing the session: the
+// retrier sleeps the recorded delays and allows as many attempts as were
+// recorded, while the function returns the recorded errors in order. Errors
+// are replayed by message only.
+func (s Session) Replay() (*Retrier, func() error) {
+	var ceil time.Duration
+	for _, a := range s.Attempts {
+		if a.Delay > ceil {
+			ceil = a.Delay
+		}
+	}
+
+	r := New(0, ceil, Attempts(len(s.Attempts)), WithStrategy(func(n int) time.Duration {
+		if n < len(s.Attempts) {
+			return s.Attempts[n].Delay
+		}
+		return ceil
+	}))
+
+	var i int
+	fn := func() error {
+		if i >= len(s.Attempts) {
+			return errors.New("retry: replay exhausted")
+		}
+		a := s.Attempts[i]
+		i++
+		if a.Err == "" {
+			return nil
+		}
+		return errors.New(a.Err)
+	}
+
+	return r, fn
+}
@@ -0,0 +1,114 @@
+package retry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+
+	var rec Recorder
+	r := New(time.Millisecond, 10*time.Millisecond, Jitter(0.3), WithRecorder(&rec))
+
+	var calls int
+	_ = r.Run(ctx, func() error {
+		calls++
+		if calls < 4 {
+			return io.EOF
+		}
+		return nil
+	})
+
+	s := rec.Session()
+	if len(s.Attempts) != 4 || !s.Success {
+		t.Fatalf("unexpected session: %+v", s)
+	}
+	for i, a := range s.Attempts[:3] {
+		if a.Attempt != i+1 || a.Err != "EOF" {
+			t.Fatalf("attempt %d recorded as %+v", i+1, a)
+		}
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded Session
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, s) {
+		t.Fatalf("round trip changed session:\n%+v\n%+v", decoded, s)
+	}
+
+	// Replaying yields the same session.
+	var replayed Recorder
+	rr, fn := decoded.Replay()
+	WithRecorder(&replayed)(rr)
+	if err := rr.Run(ctx, fn); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if !reflect.DeepEqual(replayed.Session(), s) {
+		t.Fatalf("replay diverged:\n%+v\n%+v", replayed.Session(), s)
+	}
+}
+
+func TestRecorder_GiveUp(t *testing.T) {
+	ctx := context.Background()
+
+	var rec Recorder
+	r := New(0, 0, Attempts(2), WithRecorder(&rec))
+	_ = r.Run(ctx, func() error { return io.EOF })
+
+	s := rec.Session()
+	if s.Success || s.Err != "EOF" || len(s.Attempts) != 2 {
+		t.Fatalf("unexpected session: %+v", s)
+	}
+
+	rr, fn := s.Replay()
+	if err := rr.Run(ctx, fn); err == nil || err.Error() != "EOF" {
+		t.Fatalf("replay returned %v, want EOF", err)
+	}
+}
+
+func TestRecorder_Concurrent(t *testing.T) {
+	ctx := context.Background()
+
+	var rec Recorder
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := New(0, 0, Attempts(3), WithRecorder(&rec))
+			for j := 0; j < 50; j++ {
+				_ = r.Run(ctx, func() error { return io.EOF })
+				_ = rec.Session()
+				r.Reset()
+				r.Attempts = 3
+			}
+		}()
+	}
+	wg.Wait()
+
+	if s := rec.Session(); len(s.Attempts) != 3 || s.Success {
+		t.Fatalf("sessions of concurrent runs mixed up: %+v", s)
+	}
+}
+
+func TestRecorder_MaxRetained(t *testing.T) {
+	var rec Recorder
+	r := New(0, 0, Attempts(50), MaxRetained(5), WithRecorder(&rec))
+	_ = r.Run(context.Background(), func() error { return io.EOF })
+
+	s := rec.Session()
+	if len(s.Attempts) != 5 || s.Attempts[0].Attempt != 46 || s.Attempts[4].Attempt != 50 {
+		t.Fatalf("recorded %+v, want the last 5 attempts", s.Attempts)
+	}
+}
@@ -2,8 +2,12 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"math"
 	"math/rand"
+	"net/http"
+	"runtime"
+	"sync"
 	"time"
 )
 
@@ -27,6 +31,154 @@ func Jitter(j float64) Option {
 	}
 }
 
+// Base sets the delay that growth starts from, decoupling it from Floor.
+func Base(d time.Duration) Option {
+	return func(r *Retrier) {
+		r.Base = d
+	}
+}
+
+// StartAtFraction makes the first delay after the immediate attempt f*Ceil,
+// partway up the curve rather than at its bottom, e.g. to back off
+// conservatively from a backend known to be loaded. Later delays grow from
+// there by Rate. The first delay is never shorter than it would be without
+// the option, nor longer than Ceil, and the curve starts over from it after
+// Reset.
+func StartAtFraction(f float64) Option {
+	return func(r *Retrier) {
+		r.startFraction = f
+	}
+}
+
+// WithSeed seeds the random number generator used for jitter, making the
+// jitter sequence reproducible. See Seed.
+func WithSeed(seed int64) Option {
+	return func(r *Retrier) {
+		r.seed = seed
+		r.fixedSeed = true
+		r.src = nil
+	}
+}
+
+// CeilSpread randomly perturbs Ceil by up to ±f of its value once, when the
+// retrier is created, so that many retriers don't plateau at the same delay.
+// E.g. 0.1 spreads the ceiling within ±10%. The result is never below Floor.
+func CeilSpread(f float64) Option {
+	return func(r *Retrier) {
+		r.ceilSpread = f
+	}
+}
+
+// OnCeilReached sets a callback invoked the first time the delay reaches Ceil,
+// with the number of the attempt about to be made after it. It signals
+// sustained failure and fires again only after Reset.
+func OnCeilReached(fn func(attempt int)) Option {
+	return func(r *Retrier) {
+		r.onCeil = fn
+	}
+}
+
+// WithScheduler makes Wait hand its delays to schedule instead of sleeping,
+// so that an external component can coordinate the backoff of many retriers.
+// schedule returns nil once the delay should be considered elapsed, and an
+// error, e.g. on cancellation of ctx, to make Wait return false.
+func WithScheduler(schedule func(ctx context.Context, d time.Duration) error) Option {
+	return func(r *Retrier) {
+		r.scheduler = schedule
+	}
+}
+
+// spinThreshold is the delay below which a spinning retrier yields instead of
+// sleeping.
+const spinThreshold = 50 * time.Microsecond
+
+// FixedCadence makes Wait space the attempts it allows interval apart,
+// measured from the end of one Wait to the end of the next, rather than
+// backing off. The time spent between two Waits, e.g. executing the attempt,
+// is subtracted from the sleep, which is never negative, so that polling
+// keeps a fixed frequency regardless of how long attempts take. Delays set
+// through Retry-After style overrides still take precedence.
+func FixedCadence(interval time.Duration) Option {
+	return func(r *Retrier) {
+		r.cadence = interval
+	}
+}
+
+// GrowAfterFailure ties backoff growth to observed failures: Wait grows the
+// delay only if the attempt it follows failed, and repeats the previous delay
+// otherwise. Run observes failures itself, loops calling Wait directly report
+// them with Failed.
+func GrowAfterFailure() Option {
+	return func(r *Retrier) {
+		r.growAfterFailure = true
+	}
+}
+
+// Failed reports that the attempt allowed by the last Wait failed, letting
+// the next Wait grow the delay under GrowAfterFailure. Run calls it for every
+// failed attempt.
+func (r *Retrier) Failed() {
+	r.failed = true
+}
+
+// Spin makes Wait yield the processor with runtime.Gosched instead of
+// sleeping when the delay is below a few tens of microseconds, sparing the
+// timer. It suits tight lock-free loops, e.g. compare-and-swap retries with a
+// zero Floor, that expect to succeed almost at once.
+func Spin() Option {
+	return func(r *Retrier) {
+		r.spin = true
+	}
+}
+
+// WithClock replaces time.Now as the source of the readings the retrier
+// measures elapsed time with, e.g. for the timings of Run, FixedCadence,
+// FlapReset or MinGapBetweenRuns, and of the current time, e.g. for the
+// Retry-After dates of RetryAfter. Sleeping and context deadlines still use
+// the runtime timers, and so does the time left until a context deadline, as
+// in Nested and StopTrace. Readings of time.Now carry a monotonic clock,
+// immune to wall clock steps; for clocks without one, elapsed times that would
+// come out negative after a backward step count as zero rather than as huge
+// waits.
+func WithClock(now func() time.Time) Option {
+	return func(r *Retrier) {
+		r.clock = now
+	}
+}
+
+// now returns the current time of the retrier's clock.
+func (r *Retrier) now() time.Time {
+	if r.clock != nil {
+		return r.clock()
+	}
+	return time.Now()
+}
+
+// since returns the time elapsed since t, never negative.
+func (r *Retrier) since(t time.Time) time.Duration {
+	d := r.now().Sub(t)
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// RetryWhileOpen makes Wait return false once ch is closed, like on context
+// cancellation. It suits broadcast stop signals shared by many retry loops.
+// Whichever of ctx and ch ends first stops the retrier.
+func RetryWhileOpen(ch <-chan struct{}) Option {
+	return func(r *Retrier) {
+		r.open = ch
+	}
+}
+
+// CapAfter pins the delay once the given attempt is reached.
+func CapAfter(attempt int) Option {
+	return func(r *Retrier) {
+		r.CapAfter = attempt
+	}
+}
+
 // Retrier implements an exponentially backing off retry instance.
 // Use New instead of creating this object directly.
 type Retrier struct {
@@ -40,8 +192,58 @@ type Retrier struct {
 	// Floor and Ceil are the minimum and maximum delays.
 	Floor, Ceil time.Duration
 
+	// Base is the delay that growth starts from. When zero, growth starts
+	// from Floor.
+	//
+	// Floor, Base and Ceil interact as follows: after the first (immediate)
+	// attempt the delay is set to max(Floor, Base) and then grows by Rate on
+	// every Wait. Floor is a lower clamp on every delay except the first, and
+	// Ceil is an upper clamp on every delay. Setting Floor to 0 and Base to a
+	// positive value keeps retries cheap at the bottom of the curve while still
+	// backing off exponentially.
+	Base time.Duration
+
+	// CapAfter is the attempt after which the delay stops growing. Once the
+	// CapAfter-th attempt is reached, every following delay is pinned to the
+	// delay at that attempt, which may be below Ceil. Disabled when zero.
+	CapAfter int
+
+	// Timeout bounds the total duration of Run, including attempts and
+	// backoff. Unlimited when zero.
+	Timeout time.Duration
+
+	// MaxSameError is the number of consecutive identical errors after which
+	// Run gives up, on the theory that an error that does not change is
+	// permanent. Errors are identical if errors.Is matches them or their
+	// messages are equal. A different error restarts the count. Disabled when
+	// zero.
+	MaxSameError int
+
+	// MaxBackoffRatio is the maximum ratio of the time Run spends backing off
+	// to the time it spends executing attempts. Run gives up after a failed
+	// attempt once the ratio is exceeded, catching aggressive backoff against
+	// fast failing functions. Disabled when zero.
+	MaxBackoffRatio float64
+
+	// SlowAttemptThreshold is the execution time above which Run treats a
+	// successful attempt as failed with ErrSlowAttempt, on the theory that a
+	// slow success indicates degradation. This is aggressive: the work of the
+	// slow attempt is discarded and the function must be safe to repeat.
+	// Disabled when zero.
+	SlowAttemptThreshold time.Duration
+
+	// MaxRetained is the maximum number of entries kept by any history the
+	// retrier records, such as the one enabled by RecordErrors. Older entries
+	// are dropped first. Unlimited when zero; long-lived retriers that record
+	// history should set it to keep memory bounded.
+	MaxRetained int
+
 	// Rate is the rate at which the delay grows.
 	// E.g. 2 means the delay doubles each time.
+	//
+	// With Rate 1 and Floor equal to Ceil the retrier is a fixed interval
+	// timer: after the first, immediate attempt every delay is exactly Floor,
+	// regardless of Jitter.
 	Rate float64
 
 	// Jitter determines the level of indeterminism in the delay.
@@ -53,6 +255,146 @@ type Retrier struct {
 	//
 	// Jitter can help avoid thundering herds.
 	Jitter float64
+
+	attempt int
+	slept   time.Duration
+
+	override    time.Duration
+	hasOverride bool
+
+	capped   bool
+	capDelay time.Duration
+
+	sameErr   error
+	sameCount int
+
+	cond    func(err error) bool
+	retryOn []error
+	abortOn []error
+
+	abortOnCtxErr bool
+	joined        Joined
+
+	flapWindow  time.Duration
+	lastFailure time.Time
+
+	preflight      func() error
+	resetOnSuccess bool
+
+	errorDelay func(err error) (time.Duration, bool)
+
+	retryWhile   func() bool
+	preferCtxErr bool
+	onCancel     func(ctx context.Context, lastErr error) error
+	acceptLast   func(err error) bool
+
+	tiePrefer StopReason
+	traceStop bool
+	verbose   func(step string, detail any)
+	stopTrace StopTrace
+
+	minAttempts int
+	lifetime    int
+	executions  int
+
+	minGap     time.Duration
+	lastRunEnd time.Time
+
+	winner Winner
+	won    bool
+
+	// until is the deadline of the ongoing RunUntil, zero if there is none.
+	until time.Time
+
+	// deadline is the deadline of the ongoing Run, zero if it has none.
+	deadline time.Time
+	// owedDeadline ends the backoff before an attempt owed to MinAttempts,
+	// zero for other attempts.
+	owedDeadline time.Time
+
+	logSummary func(attempts int, elapsed time.Duration, err error)
+
+	onTiming func(attempt int, exec, backoff time.Duration)
+
+	cleanup      func(ctx context.Context) error
+	cleanupGrace time.Duration
+
+	recordErrors   bool
+	coalesceErrors bool
+	errs           []ErrorRecord
+	usedDelays     []time.Duration
+
+	headerBackoff func(http.Header) (time.Duration, bool)
+	serverTime    func() time.Time
+
+	ceilSpread float64
+
+	strategy Strategy
+
+	latencyTarget time.Duration
+	latency       time.Duration
+
+	latencyPercentile float64
+	latencies         []time.Duration
+	latencyPos        int
+
+	slowDownFactor float64
+	slowDownMax    time.Duration
+	ceilBoost      float64
+
+	instanceID   int64
+	hostSalt     int64
+	seededJitter bool
+
+	taperJitter    bool
+	pooledRNG      bool
+	noJitterOnLast bool
+	startAttempts  int
+	startFraction  float64
+	stagger        time.Duration
+	randFunc       func() float64
+
+	seed      int64
+	fixedSeed bool
+	src       *rand.Rand
+
+	open       <-chan struct{}
+	closed     *closer
+	abortToken *AbortToken
+	sw         *Switch
+	scheduler  func(ctx context.Context, d time.Duration) error
+	clock      func() time.Time
+	chaos      func(attempt int) error
+	recorder   *Recorder
+	recording  Session
+	spin       bool
+	trace      bool
+
+	growAfterFailure bool
+	failed           bool
+
+	cadence   time.Duration
+	lastStart time.Time
+
+	// configuredAttempts is Attempts as set up by New, restored by Clone.
+	configuredAttempts int
+
+	// sleepCut reports whether the last Wait returned false as its sleep was
+	// cut short, rather than refusing the attempt.
+	sleepCut bool
+
+	onCeil      func(attempt int)
+	ceilReached bool
+
+	recent *attemptRing
+	rate   *rateWindow
+	shared *sharedRuns
+
+	permits *permitState
+
+	events        chan<- Event
+	fields        *map[string]any
+	droppedEvents int
 }
 
 // New creates a retrier that exponentially backs off from floor to ceil pauses.
@@ -65,43 +407,192 @@ func New(floor, ceil time.Duration, opts ...Option) *Retrier {
 		// Phi scales more calmly than 2, but still has nice pleasing
 		// properties.
 		Rate: math.Phi,
+
+		closed: newCloser(),
+		rate:   newRateWindow(),
+		shared: &sharedRuns{},
 	}
 
 	for _, setOpt := range opts {
 		setOpt(r)
 	}
 
+	if r.ceilSpread != 0 {
+		r.spreadCeil()
+	}
+	r.configuredAttempts = r.Attempts
+
 	return r
 }
 
-func applyJitter(d time.Duration, jitter float64) time.Duration {
-	if jitter == 0 {
+// Seed returns the seed of the random number generator used for jitter.
+// Passing it to WithSeed reproduces the jitter sequence of this retrier, on
+// the same host if HostnameJitter is set.
+func (r *Retrier) Seed() int64 {
+	r.rng()
+	return r.seed
+}
+
+// rng returns the random number generator of the retrier, creating it on
+// first use.
+func (r *Retrier) rng() *rand.Rand {
+	if r.src == nil {
+		if !r.fixedSeed {
+			r.seed = rand.Int63()
+		}
+		r.src = rand.New(rand.NewSource(r.seed ^ r.hostSalt))
+	}
+	return r.src
+}
+
+func (r *Retrier) spreadCeil() {
+	u := r.rng().Float64
+	if r.randFunc != nil {
+		u = r.randFunc
+	}
+	f := (u()*2 - 1) * r.ceilSpread
+	r.Ceil = time.Duration(float64(r.Ceil) * (1 + f))
+	if r.Ceil < r.Floor {
+		r.Ceil = r.Floor
+	}
+}
+
+func (r *Retrier) applyJitter(d time.Duration) time.Duration {
+	if r.Jitter == 0 {
+		return d
+	}
+
+	j := r.Jitter * r.jitterScale()
+	if j == 0 {
 		return d
 	}
+	return toDuration(r.normFloat64()*(j*float64(d)) + float64(d))
+}
+
+// scale returns d multiplied by f, saturating instead of overflowing.
+func scale(d time.Duration, f float64) time.Duration {
+	return toDuration(float64(d) * f)
+}
+
+// toDuration converts nanoseconds to a duration, saturating at the bounds of
+// time.Duration. Converting an out of range float64 to an integer is
+// implementation defined in Go and typically yields a negative value.
+func toDuration(ns float64) time.Duration {
+	switch {
+	case ns >= math.MaxInt64:
+		return math.MaxInt64
+	case ns <= math.MinInt64:
+		return math.MinInt64
+	}
+	return time.Duration(ns)
+}
+
+// anchor returns the delay that growth starts from.
+func (r *Retrier) anchor() time.Duration {
+	a := r.Floor
+	if r.Base > a {
+		a = r.Base
+	}
+	if r.startFraction > 0 {
+		d := r.startFraction * float64(r.Ceil)
+		if r.Rate > 1 {
+			// The first delay is grown from the anchor.
+			d /= r.Rate
+		}
+		if d := toDuration(d); d > a {
+			a = d
+		}
+	}
+	return a
+}
+
+// fixed reports whether the retrier is configured as a fixed interval timer.
+func (r *Retrier) fixed() bool {
+	return r.strategy == nil && r.Rate == 1 && r.Floor == r.Ceil
+}
+
+// next computes the delay before the next attempt.
+func (r *Retrier) next() time.Duration {
+	if r.fixed() {
+		// Every delay but the first, immediate one is exactly Floor.
+		if r.attempt == 0 {
+			return 0
+		}
+		if r.ceilBoost > 1 {
+			// SlowDownCeil raises the fixed delay along with the ceiling.
+			return r.ceil()
+		}
+		return r.Floor
+	}
+
+	ceil := r.ceil()
+	d := r.Delay
+	switch {
+	case r.strategy != nil:
+		if r.attempt == 0 {
+			return 0
+		}
+		d = r.strategy(r.attempt)
+	case r.capped:
+		d = r.capDelay
+	case d < ceil && (!r.growAfterFailure || r.failed):
+		d = scale(d, r.Rate)
+	}
+	if g := r.latencyGain(); g != 1 {
+		d = scale(d, g)
+	}
+	if r.verbose != nil {
+		r.verbose("delay.raw", d)
+	}
 
-	d = time.Duration(rand.NormFloat64()*(jitter*float64(d)) + float64(d))
+	d = r.applyJitter(d)
+	if r.verbose != nil {
+		r.verbose("delay.jittered", d)
+	}
 
+	if d > ceil {
+		d = ceil
+	}
+	if d != 0 && d < r.Floor {
+		d = r.Floor
+	}
+	if r.verbose != nil {
+		r.verbose("delay.clamped", d)
+	}
 	return d
 }
 
 // Wait returns after min(Delay*Growth, Ceil) or ctx is cancelled.
 // The first call to Wait will return immediately.
 func (r *Retrier) Wait(ctx context.Context) bool {
+	r.sleepCut = false
 	select {
 	case <-ctx.Done():
 		return false
+	case <-r.open:
+		return false
+	case <-r.closed.done():
+		return false
+	case <-r.abortToken.done():
+		return false
 	default:
 	}
 
-	if r.Delay < r.Ceil {
-		r.Delay = time.Duration(float64(r.Delay) * r.Rate)
+	if r.sw != nil && !isCritical(ctx) {
+		if !r.sw.wait(ctx, r.open, r.closed.done(), r.abortToken.done()) || !r.stillOpen(ctx) {
+			return false
+		}
 	}
 
-	r.Delay = applyJitter(r.Delay, r.Jitter)
+	if !r.until.IsZero() && !r.now().Before(r.until) {
+		return false
+	}
 
-	if r.Delay > r.Ceil {
-		r.Delay = r.Ceil
+	if r.attempt == 0 {
+		r.startAttempts = r.Attempts
 	}
+	r.Delay = r.next()
+	r.failed = false
 
 	if r.Attempts >= 0 {
 		a := r.Attempts - 1
@@ -111,18 +602,240 @@ func (r *Retrier) Wait(ctx context.Context) bool {
 		r.Attempts = a
 	}
 
-	select {
-	case <-time.After(r.Delay):
-		if r.Delay < r.Floor {
-			r.Delay = r.Floor
+	if c := r.ceil(); c > 0 && r.Delay >= c && !r.ceilReached {
+		r.ceilReached = true
+		if r.onCeil != nil {
+			r.onCeil(r.attempt + 1)
+		}
+	}
+
+	sleep := r.Delay
+	switch {
+	case r.hasOverride:
+		sleep = r.override
+		r.hasOverride = false
+	case r.cadence > 0 && !r.lastStart.IsZero():
+		sleep = r.cadence - r.since(r.lastStart)
+		if sleep < 0 {
+			sleep = 0
+		}
+	}
+	if r.attempt == 0 && r.stagger > 0 {
+		sleep += toDuration(r.uniform() * (float64(r.stagger) + 1))
+	}
+
+	if !r.until.IsZero() {
+		// Make the last attempt at the deadline rather than overshoot it.
+		if left := r.until.Sub(r.now()); sleep > left {
+			sleep = left
+		}
+	}
+
+	if !r.owedDeadline.IsZero() {
+		if left := r.owedDeadline.Sub(r.now()); sleep > left {
+			sleep = left
+			if sleep < 0 {
+				sleep = 0
+			}
+		}
+	}
+
+	if r.verbose != nil {
+		r.verbose("sleep", sleep)
+	}
+	if !r.sleep(ctx, sleep) {
+		r.sleepCut = true
+		return false
+	}
+	r.advance(sleep)
+	if r.cadence > 0 {
+		r.lastStart = r.now()
+	}
+	return true
+}
+
+// sleep waits for d, or hands it to the scheduler if one is set. It returns
+// false if the wait was cut short.
+func (r *Retrier) sleep(ctx context.Context, d time.Duration) bool {
+	if r.scheduler != nil {
+		if r.scheduler(ctx, d) != nil {
+			return false
 		}
+		return r.stillOpen(ctx)
+	}
+
+	if r.spin && d < spinThreshold {
+		runtime.Gosched()
+		return r.stillOpen(ctx)
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
 		return true
 	case <-ctx.Done():
 		return false
+	case <-r.open:
+		return false
+	case <-r.closed.done():
+		return false
+	case <-r.abortToken.done():
+		return false
+	}
+}
+
+// ErrNotClosable is returned by Close for retriers not created by New, Clone
+// or Fork.
+var ErrNotClosable = errors.New("retry: retrier can't be closed")
+
+// Close stops the retrier: Wait calls in progress return false promptly, and
+// so do all future ones, like on cancellation of their context. It gives
+// components owning a retrier, e.g. a reconnect loop, a shutdown handle
+// without threading a context through. A delay handed to a WithScheduler
+// scheduler isn't interrupted. Close may be called concurrently with Wait and
+// repeatedly. Retriers not created by New, Clone or Fork, e.g. declared as a
+// struct literal, can't be closed: Close returns ErrNotClosable for them and
+// has no effect.
+func (r *Retrier) Close() error {
+	if r.closed == nil {
+		return ErrNotClosable
+	}
+	r.closed.close()
+	return nil
+}
+
+// closer is a channel closed once.
+type closer struct {
+	once sync.Once
+	ch   chan struct{}
+}
+
+func newCloser() *closer {
+	return &closer{ch: make(chan struct{})}
+}
+
+func (c *closer) close() {
+	if c == nil {
+		return
+	}
+	c.once.Do(func() { close(c.ch) })
+}
+
+// done returns the channel, nil for a nil closer so that it blocks forever.
+func (c *closer) done() <-chan struct{} {
+	if c == nil {
+		return nil
+	}
+	return c.ch
+}
+
+// stillOpen reports whether neither ctx nor the open channel has ended, and
+// the retrier wasn't closed.
+func (r *Retrier) stillOpen(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-r.open:
+		return false
+	case <-r.closed.done():
+		return false
+	case <-r.abortToken.done():
+		return false
+	default:
+		return true
+	}
+}
+
+// overrideNext makes the next Wait sleep d, clamped to Ceil, instead of the
+// computed delay. The backoff curve keeps advancing underneath.
+func (r *Retrier) overrideNext(d time.Duration) {
+	if c := r.ceil(); d > c {
+		d = c
+	}
+	if d < 0 {
+		d = 0
+	}
+	r.override = d
+	r.hasOverride = true
+}
+
+// advance updates the state after slept was slept.
+func (r *Retrier) advance(slept time.Duration) {
+	r.slept = slept
+	if a := r.anchor(); r.Delay < a {
+		r.Delay = a
+	}
+	r.attempt++
+	if r.CapAfter > 0 && r.attempt == r.CapAfter {
+		r.capped = true
+		r.capDelay = r.Delay
 	}
 }
 
-// Reset resets the retrier to its initial state.
+// Sleep is like Wait with a background context. It is meant for simple
+// scripts that have no context to pass around.
+func (r *Retrier) Sleep() bool {
+	return r.Wait(context.Background())
+}
+
+// Clone returns a copy of the retrier with the same configuration and its
+// runtime state reset. The clone gets the Attempts r was created with, not
+// those left after its runs.
+func (r *Retrier) Clone() *Retrier {
+	c := *r
+	c.Reset()
+	if r.closed != nil {
+		// Created by New, Clone or Fork, so configuredAttempts is set.
+		c.Attempts = r.configuredAttempts
+	}
+	c.recent = r.recent.clone(false)
+	c.lastRunEnd = time.Time{}
+	c.executions = 0
+	c.ceilBoost = 0
+	c.closed = newCloser()
+	c.rate = newRateWindow()
+	c.shared = &sharedRuns{}
+	// Clones must not share the generator. Unless seeded with WithSeed, they
+	// get a fresh seed.
+	c.src = nil
+	return &c
+}
+
+// Fork returns a copy of the retrier that continues from its current state,
+// including Delay and Attempts, and evolves independently afterwards.
+// Unlike Clone, the runtime state is preserved.
+func (r *Retrier) Fork() *Retrier {
+	c := *r
+	c.errs = append([]ErrorRecord(nil), r.errs...)
+	c.usedDelays = append([]time.Duration(nil), r.usedDelays...)
+	c.latencies = append([]time.Duration(nil), r.latencies...)
+	c.recent = r.recent.clone(true)
+	c.closed = newCloser()
+	c.rate = newRateWindow()
+	c.shared = &sharedRuns{}
+	c.src = nil
+	return &c
+}
+
+// Reset resets the retrier to its initial state. The ceiling raised by
+// SlowDownCeil is kept.
 func (r *Retrier) Reset() {
 	r.Delay = 0
+	r.attempt = 0
+	r.slept = 0
+	r.hasOverride = false
+	r.latency = 0
+	r.latencies = nil
+	r.latencyPos = 0
+	r.capped = false
+	r.ceilReached = false
+	r.lastFailure = time.Time{}
+	r.lastStart = time.Time{}
+	r.failed = false
+	r.errs = nil
+	r.usedDelays = nil
+	r.sameErr = nil
+	r.sameCount = 0
 }
@@ -2,6 +2,7 @@ package retry
 
 import (
 	"context"
+	"io"
 	"math"
 	"testing"
 	"time"
@@ -61,6 +62,55 @@ func TestReset(t *testing.T) {
 	r.Wait(ctx)
 }
 
+func TestSleep(t *testing.T) {
+	ctx := context.Background()
+
+	slept := New(time.Millisecond, 10*time.Millisecond, Rate(2), Attempts(4))
+	waited := New(time.Millisecond, 10*time.Millisecond, Rate(2), Attempts(4))
+
+	for i := 0; i < 4; i++ {
+		if !slept.Sleep() {
+			t.Fatalf("attempt %d not allowed", i)
+		}
+		waited.Wait(ctx)
+		if slept.Delay != waited.Delay {
+			t.Fatalf("attempt %d: delay %v, want %v", i, slept.Delay, waited.Delay)
+		}
+	}
+	if slept.Sleep() {
+		t.Fatalf("attempt allowed past Attempts")
+	}
+}
+
+func TestCapAfter(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(time.Millisecond, time.Second, Rate(2), CapAfter(3))
+
+	want := []time.Duration{
+		time.Millisecond,
+		2 * time.Millisecond,
+		4 * time.Millisecond,
+		4 * time.Millisecond,
+		4 * time.Millisecond,
+		4 * time.Millisecond,
+	}
+	for i, w := range want {
+		r.Wait(ctx)
+		if r.Delay != w {
+			t.Fatalf("attempt %d: delay %v, want %v", i+1, r.Delay, w)
+		}
+	}
+
+	r.Reset()
+	for i := 0; i < 4; i++ {
+		r.Wait(ctx)
+	}
+	if r.Delay != 4*time.Millisecond {
+		t.Fatalf("plateau not restored after Reset: %v", r.Delay)
+	}
+}
+
 func TestJitter_Normal(t *testing.T) {
 	t.Parallel()
 
@@ -91,6 +141,221 @@ func TestJitter_Normal(t *testing.T) {
 	t.Logf("sample: %v", waits[len(waits)-10:])
 }
 
+func TestWithSeed(t *testing.T) {
+	ctx := context.Background()
+
+	delays := func(r *Retrier) []time.Duration {
+		var ds []time.Duration
+		for i := 0; i < 10; i++ {
+			r.Wait(ctx)
+			ds = append(ds, r.Delay)
+		}
+		return ds
+	}
+	newRetrier := func(opts ...Option) *Retrier {
+		return New(time.Microsecond, time.Millisecond, append([]Option{Jitter(0.5)}, opts...)...)
+	}
+
+	orig := newRetrier()
+	want := delays(orig)
+
+	for _, r := range []*Retrier{
+		newRetrier(WithSeed(orig.Seed())),
+		newRetrier(WithSeed(orig.Seed())),
+	} {
+		got := delays(r)
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("delay %d = %v, want %v", i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestCeilSpread(t *testing.T) {
+	const (
+		ceil   = time.Second
+		spread = 0.1
+	)
+
+	lo, hi := ceil, ceil
+	for i := 0; i < 1000; i++ {
+		c := New(time.Millisecond, ceil, CeilSpread(spread)).Ceil
+		if c < lo {
+			lo = c
+		}
+		if c > hi {
+			hi = c
+		}
+	}
+
+	if lo < ceil-ceil/10 || hi > ceil+ceil/10 {
+		t.Fatalf("ceilings outside of spread: [%v, %v]", lo, hi)
+	}
+	// The ceilings should cover most of the allowed range.
+	if lo > ceil-ceil/20 || hi < ceil+ceil/20 {
+		t.Fatalf("ceilings not spread: [%v, %v]", lo, hi)
+	}
+}
+
+func TestFork(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(time.Millisecond, time.Second, Rate(2), Attempts(5))
+	r.Wait(ctx)
+	r.Wait(ctx)
+
+	f := r.Fork()
+	if f.Delay != r.Delay || f.Attempts != r.Attempts {
+		t.Fatalf("fork did not start from parent state: %v/%d, want %v/%d",
+			f.Delay, f.Attempts, r.Delay, r.Attempts)
+	}
+
+	r.Wait(ctx)
+	if f.Delay != 2*time.Millisecond || f.Attempts != 3 {
+		t.Fatalf("fork affected by parent: %v/%d", f.Delay, f.Attempts)
+	}
+
+	f.Wait(ctx)
+	f.Wait(ctx)
+	if r.Delay != 4*time.Millisecond || r.Attempts != 2 {
+		t.Fatalf("parent affected by fork: %v/%d", r.Delay, r.Attempts)
+	}
+	if f.Delay != 8*time.Millisecond || f.Attempts != 1 {
+		t.Fatalf("fork did not continue the schedule: %v/%d", f.Delay, f.Attempts)
+	}
+}
+
+func TestFixedInterval(t *testing.T) {
+	ctx := context.Background()
+
+	const interval = 5 * time.Millisecond
+
+	r := New(interval, interval, Rate(1), Jitter(0.5))
+	r.Wait(ctx)
+
+	for i := 0; i < 20; i++ {
+		start := time.Now()
+		r.Wait(ctx)
+		took := time.Since(start)
+		if r.Delay != interval {
+			t.Fatalf("interval %d: delay %v, want %v", i, r.Delay, interval)
+		}
+		if took < interval {
+			t.Fatalf("interval %d: took %v, want at least %v", i, took, interval)
+		}
+	}
+}
+
+func TestHugeCeilNoOverflow(t *testing.T) {
+	r := New(time.Millisecond, math.MaxInt64, Rate(1e6))
+
+	// Drive the growth by hand to avoid sleeping.
+	r.Delay = time.Millisecond
+	for i := 0; i < 100; i++ {
+		r.Delay = r.next()
+		if r.Delay < time.Millisecond {
+			t.Fatalf("step %d: delay overflowed to %v", i, r.Delay)
+		}
+	}
+	if r.Delay != math.MaxInt64 {
+		t.Fatalf("delay did not saturate at ceil: %v", r.Delay)
+	}
+
+	r.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := r.next(); d < time.Millisecond {
+			t.Fatalf("step %d: jittered delay overflowed to %v", i, d)
+		}
+	}
+}
+
+func TestOnCeilReached(t *testing.T) {
+	ctx := context.Background()
+
+	var fired []int
+	r := New(time.Millisecond, 4*time.Millisecond, Rate(2), OnCeilReached(func(attempt int) {
+		fired = append(fired, attempt)
+	}))
+
+	// Delays are 0, 2ms, 4ms, 4ms, ...
+	for i := 0; i < 6; i++ {
+		r.Wait(ctx)
+	}
+	if len(fired) != 1 || fired[0] != 3 {
+		t.Fatalf("fired at %v, want once at attempt 3", fired)
+	}
+
+	r.Reset()
+	for i := 0; i < 3; i++ {
+		r.Wait(ctx)
+	}
+	if len(fired) != 2 || fired[1] != 3 {
+		t.Fatalf("fired at %v after Reset, want again at attempt 3", fired)
+	}
+}
+
+func TestWithScheduler(t *testing.T) {
+	ctx := context.Background()
+
+	var requested []time.Duration
+	r := New(time.Second, time.Hour, Rate(2), Attempts(5), WithScheduler(func(ctx context.Context, d time.Duration) error {
+		requested = append(requested, d)
+		return nil
+	}))
+
+	start := time.Now()
+	for r.Wait(ctx) {
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("Wait slept locally for %v", took)
+	}
+
+	want := []time.Duration{0, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second}
+	if len(requested) != len(want) {
+		t.Fatalf("scheduler got %v, want %v", requested, want)
+	}
+	for i := range want {
+		if requested[i] != want[i] {
+			t.Fatalf("delay %d = %v, want %v", i, requested[i], want[i])
+		}
+	}
+
+	r = New(time.Second, time.Hour, WithScheduler(func(ctx context.Context, d time.Duration) error {
+		return context.Canceled
+	}))
+	if r.Wait(ctx) {
+		t.Fatalf("attempt allowed although the scheduler failed")
+	}
+}
+
+func TestRetryWhileOpen(t *testing.T) {
+	ctx := context.Background()
+
+	ch := make(chan struct{})
+	r := New(time.Hour, time.Hour, RetryWhileOpen(ch))
+
+	if !r.Wait(ctx) {
+		t.Fatalf("first attempt not allowed")
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(ch)
+	}()
+
+	start := time.Now()
+	if r.Wait(ctx) {
+		t.Fatalf("attempt allowed after channel closed")
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("Wait took %v to notice the closed channel", took)
+	}
+	if r.Wait(ctx) {
+		t.Fatalf("attempt allowed on closed channel")
+	}
+}
+
 // stdDev returns the standard deviation of the sample.
 func stdDev(sample []float64) float64 {
 	if len(sample) == 0 {
@@ -110,3 +375,254 @@ func stdDev(sample []float64) float64 {
 
 	return math.Sqrt(variance)
 }
+
+func TestBase(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(0, time.Second, Base(10*time.Millisecond), Rate(2))
+
+	// The first wait is immediate, after which the delay is anchored to Base
+	// rather than Floor.
+	want := []time.Duration{
+		10 * time.Millisecond,
+		20 * time.Millisecond,
+		40 * time.Millisecond,
+		80 * time.Millisecond,
+	}
+	for i, w := range want {
+		if !r.Wait(ctx) {
+			t.Fatalf("attempt %d not allowed", i)
+		}
+		if r.Delay != w {
+			t.Fatalf("attempt %d: delay %v, want %v", i, r.Delay, w)
+		}
+	}
+}
+
+func TestBase_BelowFloor(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(20*time.Millisecond, time.Second, Base(time.Millisecond), Rate(2))
+	r.Wait(ctx)
+	if r.Delay != 20*time.Millisecond {
+		t.Fatalf("floor not respected: %v", r.Delay)
+	}
+	r.Wait(ctx)
+	if r.Delay != 40*time.Millisecond {
+		t.Fatalf("did not grow from floor: %v", r.Delay)
+	}
+}
+
+func TestSpin(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(0, 0, Spin(), Attempts(1000))
+	tt := time.Now()
+	var n int
+	for r.Wait(ctx) {
+		n++
+	}
+	if n != 1000 {
+		t.Fatalf("got %d attempts, want 1000", n)
+	}
+	if time.Since(tt) > time.Second {
+		t.Fatalf("spinning took too long")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	r = New(0, 0, Spin())
+	r.Wait(ctx)
+	cancel()
+	if r.Wait(ctx) {
+		t.Fatalf("attempt allowed even though context cancelled")
+	}
+}
+
+func BenchmarkWaitNearZero(b *testing.B) {
+	ctx := context.Background()
+	for _, bm := range []struct {
+		name string
+		opts []Option
+	}{
+		{"Timer", nil},
+		{"Spin", []Option{Spin()}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			r := New(time.Microsecond, time.Microsecond, bm.opts...)
+			for i := 0; i < b.N; i++ {
+				r.Wait(ctx)
+			}
+		})
+	}
+}
+
+func TestFixedCadence(t *testing.T) {
+	ctx := context.Background()
+
+	const interval = 30 * time.Millisecond
+	r := New(time.Hour, time.Hour, FixedCadence(interval), Attempts(5))
+
+	work := []time.Duration{0, 20 * time.Millisecond, 5 * time.Millisecond, 15 * time.Millisecond, 0}
+	tt := time.Now()
+	var starts []time.Duration
+	for i := 0; r.Wait(ctx); i++ {
+		starts = append(starts, time.Since(tt))
+		time.Sleep(work[i])
+	}
+	if len(starts) != 5 {
+		t.Fatalf("got %d attempts, want 5", len(starts))
+	}
+	for i, s := range starts {
+		want := time.Duration(i) * interval
+		if s < want || s > want+interval/2 {
+			t.Fatalf("attempt %d started at %v, want about %v", i, s, want)
+		}
+	}
+}
+
+func TestGrowAfterFailure(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(time.Millisecond, time.Second, Rate(2), GrowAfterFailure())
+	var delays []time.Duration
+	for i := 0; i < 6 && r.Wait(ctx); i++ {
+		delays = append(delays, r.Delay)
+		// Only the first three attempts fail.
+		if i < 3 {
+			r.Failed()
+		}
+	}
+
+	want := []time.Duration{
+		time.Millisecond,
+		2 * time.Millisecond,
+		4 * time.Millisecond,
+		8 * time.Millisecond,
+		8 * time.Millisecond,
+		8 * time.Millisecond,
+	}
+	for i := range want {
+		if delays[i] != want[i] {
+			t.Fatalf("got delays %v, want %v", delays, want)
+		}
+	}
+}
+
+func TestStartAtFraction(t *testing.T) {
+	var sleeps []time.Duration
+	schedule := func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	r := New(time.Millisecond, time.Second, Rate(2), StartAtFraction(0.25), WithScheduler(schedule))
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		r.Wait(ctx)
+	}
+	r.Reset()
+	for i := 0; i < 2; i++ {
+		r.Wait(ctx)
+	}
+
+	want := []time.Duration{0, 250 * time.Millisecond, 500 * time.Millisecond, 0, 250 * time.Millisecond}
+	for i := range want {
+		if sleeps[i] != want[i] {
+			t.Fatalf("got sleeps %v, want %v", sleeps, want)
+		}
+	}
+
+	// The default Rate doesn't scale the first delay either.
+	sleeps = nil
+	r = New(time.Millisecond, time.Second, Jitter(0), StartAtFraction(0.25), WithScheduler(schedule))
+	r.Wait(ctx)
+	r.Wait(ctx)
+	if d := sleeps[1] - 250*time.Millisecond; d < -time.Microsecond || d > time.Microsecond {
+		t.Fatalf("first delay %v, want 250ms", sleeps[1])
+	}
+}
+
+func TestClose(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(time.Hour, time.Hour)
+	if !r.Wait(ctx) {
+		t.Fatalf("attempt not allowed")
+	}
+
+	done := make(chan bool)
+	go func() { done <- r.Wait(ctx) }()
+	time.Sleep(10 * time.Millisecond)
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case ok := <-done:
+		if ok {
+			t.Fatalf("attempt allowed after close")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Wait didn't return after close")
+	}
+
+	if r.Wait(ctx) {
+		t.Fatalf("attempt allowed after close")
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("closing twice: %v", err)
+	}
+	if err := (&Retrier{}).Close(); err != ErrNotClosable {
+		t.Fatalf("closing a struct literal: got %v, want %v", err, ErrNotClosable)
+	}
+	if !r.Clone().Wait(ctx) {
+		t.Fatalf("clone of a closed retrier is closed")
+	}
+}
+
+func TestStaggerStart(t *testing.T) {
+	ctx := context.Background()
+
+	var requested []time.Duration
+	schedule := func(ctx context.Context, d time.Duration) error {
+		requested = append(requested, d)
+		return nil
+	}
+	r := New(time.Second, time.Hour, Rate(2), Attempts(4), Jitter(0), StaggerStart(time.Minute), WithSeed(1), WithScheduler(schedule))
+
+	for r.Wait(ctx) {
+	}
+	if len(requested) != 4 {
+		t.Fatalf("got %d waits, want 4", len(requested))
+	}
+	if d := requested[0]; d <= 0 || d > time.Minute {
+		t.Fatalf("first attempt staggered by %v, want within (0, 1m]", d)
+	}
+	want := []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second}
+	for i, d := range requested[1:] {
+		if d != want[i] {
+			t.Fatalf("wait %d: got %v, want %v", i+1, d, want[i])
+		}
+	}
+}
+
+func TestClone_Attempts(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(0, 0, Attempts(3))
+	_ = r.Run(ctx, func() error { return io.EOF })
+
+	var calls int
+	_ = r.Clone().Run(ctx, func() error {
+		calls++
+		return io.EOF
+	})
+	if calls != 3 {
+		t.Fatalf("clone of a used retrier made %d attempts, want 3", calls)
+	}
+
+	p := NewPartitioned(r, 0)
+	if !p.Wait(ctx, "a") {
+		t.Fatalf("partition of a used retrier allowed no attempt")
+	}
+}
@@ -0,0 +1,56 @@
+package retry
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServerTimeHook sets the function providing the server's notion of the
+// current time, e.g. parsed from the HTTP Date header. It is used instead of
+// the local clock when RetryAfter converts a date into a delay, so client
+// clock skew does not mistime the retry.
+func ServerTimeHook(now func() time.Time) Option {
+	return func(r *Retrier) {
+		r.serverTime = now
+	}
+}
+
+// RetryAfter parses the value of a Retry-After header into a delay.
+// It accepts both the delay-seconds and the HTTP-date forms. Dates in the
+// past yield a zero delay, and delays too long for a time.Duration saturate.
+// The second result is false if v is malformed.
+func (r *Retrier) RetryAfter(v string) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		if secs > math.MaxInt64/int64(time.Second) {
+			return math.MaxInt64, true
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+
+	at, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+
+	now := r.now
+	if r.serverTime != nil {
+		now = r.serverTime
+	}
+
+	d := at.Sub(now())
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
@@ -0,0 +1,51 @@
+package retry
+
+import (
+	"math"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter_Seconds(t *testing.T) {
+	r := New(time.Second, time.Minute)
+
+	for v, want := range map[string]time.Duration{
+		"0":           0,
+		"5":           5 * time.Second,
+		" 120":        2 * time.Minute,
+		"99999999999": math.MaxInt64,
+	} {
+		d, ok := r.RetryAfter(v)
+		if !ok || d != want {
+			t.Fatalf("RetryAfter(%q) = %v, %v; want %v", v, d, ok, want)
+		}
+	}
+
+	for _, v := range []string{"", "-1", "soon"} {
+		if _, ok := r.RetryAfter(v); ok {
+			t.Fatalf("RetryAfter(%q) accepted malformed value", v)
+		}
+	}
+}
+
+func TestRetryAfter_ServerTime(t *testing.T) {
+	// The server clock runs an hour behind the local one.
+	server := time.Now().Add(-time.Hour).Truncate(time.Second)
+	at := server.Add(30 * time.Second).UTC().Format(http.TimeFormat)
+
+	skewed := New(time.Second, time.Minute)
+	if d, ok := skewed.RetryAfter(at); !ok || d != 0 {
+		t.Fatalf("local clock: RetryAfter = %v, %v; want 0", d, ok)
+	}
+
+	r := New(time.Second, time.Minute, ServerTimeHook(func() time.Time { return server }))
+	if d, ok := r.RetryAfter(at); !ok || d != 30*time.Second {
+		t.Fatalf("server clock: RetryAfter = %v, %v; want 30s", d, ok)
+	}
+
+	r = New(time.Second, time.Minute, WithClock(func() time.Time { return server }))
+	if d, ok := r.RetryAfter(at); !ok || d != 30*time.Second {
+		t.Fatalf("WithClock: RetryAfter = %v, %v; want 30s", d, ok)
+	}
+}
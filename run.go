@@ -0,0 +1,707 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/trace"
+	"time"
+)
+
+var (
+	// ErrNoAttempts is returned by Run when the retrier allowed no attempts.
+	ErrNoAttempts = errors.New("retry: no attempts made")
+
+	// ErrNilFunc is returned by Run and the helpers built on it when given a
+	// nil function.
+	ErrNilFunc = errors.New("retry: nil function")
+
+	// ErrSlowAttempt is the error of attempts that succeeded slower than the
+	// SlowAttemptThreshold.
+	ErrSlowAttempt = errors.New("retry: attempt too slow")
+
+	// ErrLifetimeExceeded is returned by Run when the retrier used up the
+	// executions allowed by LifetimeExecutions.
+	ErrLifetimeExceeded = errors.New("retry: lifetime executions exceeded")
+
+	// ErrConditionHeld is returned by Run when it stops while the RetryWhile
+	// predicate still holds.
+	ErrConditionHeld = errors.New("retry: retry condition still holds")
+)
+
+// ErrorRecord is an entry of the error history kept by Run.
+type ErrorRecord struct {
+	// Err is the error returned by the attempt.
+	Err error
+
+	// Count is the number of consecutive attempts that returned Err.
+	// It is always 1 unless CoalesceErrors is set.
+	Count int
+}
+
+// RecordErrors makes Run keep the history of errors returned by attempts.
+// The history is available through Errors and Run returns all of the recorded
+// errors joined together.
+func RecordErrors() Option {
+	return func(r *Retrier) {
+		r.recordErrors = true
+	}
+}
+
+// CoalesceErrors makes Run collapse consecutive identical errors into a single
+// history entry. It implies RecordErrors.
+func CoalesceErrors() Option {
+	return func(r *Retrier) {
+		r.recordErrors = true
+		r.coalesceErrors = true
+	}
+}
+
+// MaxSameError makes Run give up after n consecutive identical errors.
+func MaxSameError(n int) Option {
+	return func(r *Retrier) {
+		r.MaxSameError = n
+	}
+}
+
+// RetryWhile makes Run retry as long as cond returns true.
+//
+// cond is consulted after every attempt and takes precedence over the error
+// of the attempt: while it returns true the attempt is retried even if it
+// succeeded. Once it returns false, the error decides as usual. Attempts and
+// context cancellation still stop the loop.
+func RetryWhile(cond func() bool) Option {
+	return func(r *Retrier) {
+		r.retryWhile = cond
+	}
+}
+
+// CleanupOnCancel makes Run call fn once when it stops because its context was
+// cancelled. fn gets a fresh context bounded by grace, so it can make a final
+// best-effort attempt, e.g. to flush or release a resource. An error returned
+// by fn is joined to the error returned by Run.
+func CleanupOnCancel(fn func(ctx context.Context) error, grace time.Duration) Option {
+	return func(r *Retrier) {
+		r.cleanup = fn
+		r.cleanupGrace = grace
+	}
+}
+
+// MaxRetained caps every history kept by the retrier to the last n entries.
+func MaxRetained(n int) Option {
+	return func(r *Retrier) {
+		r.MaxRetained = n
+	}
+}
+
+// OnAttemptTiming sets a callback Run invokes once per attempt with its
+// execution time and the duration of the backoff that followed it. The final
+// attempt is reported with a zero backoff.
+func OnAttemptTiming(fn func(attempt int, execDuration, backoffDuration time.Duration)) Option {
+	return func(r *Retrier) {
+		r.onTiming = fn
+	}
+}
+
+// PreferContextError sets whether Run returns the context error rather than
+// the error of the last attempt when its context ends after a failed attempt.
+func PreferContextError(prefer bool) Option {
+	return func(r *Retrier) {
+		r.preferCtxErr = prefer
+	}
+}
+
+// OnCancel sets a hook choosing the error Run returns when its context ends,
+// whether cancelled or past its deadline. It receives the context and the
+// error of the last attempt, nil if none was made, and may return either,
+// both merged or anything else. It takes precedence over
+// PreferContextError.
+func OnCancel(fn func(ctx context.Context, lastErr error) error) Option {
+	return func(r *Retrier) {
+		r.onCancel = fn
+	}
+}
+
+// MaxBackoffRatio makes Run give up once the time spent backing off exceeds f
+// times the time spent executing attempts.
+func MaxBackoffRatio(f float64) Option {
+	return func(r *Retrier) {
+		r.MaxBackoffRatio = f
+	}
+}
+
+// FlapReset makes the backoff restart from the bottom of the curve when Run
+// sees a failure more than window after the previous one. Isolated failures
+// separated by healthy periods are then retried quickly, while sustained
+// failures back off as usual.
+func FlapReset(window time.Duration) Option {
+	return func(r *Retrier) {
+		r.flapWindow = window
+	}
+}
+
+// ResetOnSuccess makes every successful attempt restart the backoff from the
+// bottom of the curve. It matters for runs that continue after a success,
+// i.e. with RetryWhile, where the next failure is then retried quickly
+// instead of inheriting the backoff of earlier failures.
+func ResetOnSuccess() Option {
+	return func(r *Retrier) {
+		r.resetOnSuccess = true
+	}
+}
+
+// SlowAttemptThreshold makes Run treat attempts that succeed but take longer
+// than d as failed with ErrSlowAttempt.
+func SlowAttemptThreshold(d time.Duration) Option {
+	return func(r *Retrier) {
+		r.SlowAttemptThreshold = d
+	}
+}
+
+// ErrorDelayFunc sets a function consulted after every failed attempt. When it
+// returns true, the following delay is the returned one, clamped to Ceil,
+// instead of the computed backoff. It generalizes Retry-After to errors
+// carrying a suggested delay, like a quota error with a reset time; errors
+// it doesn't recognize are backed off as usual.
+func ErrorDelayFunc(fn func(err error) (time.Duration, bool)) Option {
+	return func(r *Retrier) {
+		r.errorDelay = fn
+	}
+}
+
+// UsedDelays returns a copy of the delays slept between the attempts of the
+// last Run, including jitter. At most MaxRetained delays are kept, or 1024
+// when MaxRetained is zero.
+func (r *Retrier) UsedDelays() []time.Duration {
+	return append([]time.Duration(nil), r.usedDelays...)
+}
+
+// Winner describes the attempt that made a run succeed.
+type Winner struct {
+	// Attempt is the number of the successful attempt, starting at 1.
+	Attempt int
+
+	// Target is the index of the function that succeeded among those passed
+	// to Any, and 0 for runs of a single function.
+	Target int
+}
+
+// WinnerInfo returns the attempt that made the last run succeed, e.g. to log
+// which replica answered or to route the next call to it. It reports false
+// if the last run failed or there was none.
+func (r *Retrier) WinnerInfo() (Winner, bool) {
+	return r.winner, r.won
+}
+
+// Preflight sets a check Run performs once before the first attempt. If it
+// fails, Run returns its error right away without making any attempt. Unlike
+// a failed attempt, a failed preflight is always terminal, which suits
+// detecting requests that are invalid and would never succeed.
+func Preflight(check func() error) Option {
+	return func(r *Retrier) {
+		r.preflight = check
+	}
+}
+
+// MinAttempts makes Run make at least n attempts even if Timeout passes
+// before, overrunning it slightly. MinAttempts takes precedence over Timeout
+// for the count of attempts only, not for their duration: backoffs before
+// owed attempts end at the deadline at the latest, so those owed past it
+// follow each other immediately, and each owed attempt taking a context, as
+// with RunWithDeadlines, gets one bounded by Timeout of its own. No further
+// attempts are made past the deadline, and the run fails as timed out.
+// Attempts, cancellation of the context and the other limits stop the run as
+// usual.
+func MinAttempts(n int) Option {
+	return func(r *Retrier) {
+		r.minAttempts = n
+	}
+}
+
+// Timeout bounds the total duration of Run.
+func Timeout(d time.Duration) Option {
+	return func(r *Retrier) {
+		r.Timeout = d
+	}
+}
+
+// AcceptLastOnExhaustion makes Run return nil when it runs out of attempts
+// and accept reports true for the error of the last attempt. It suits
+// best-effort steps that should try hard, but not fail the whole operation
+// with a tolerable error. Runs that stop for any other reason are unaffected.
+func AcceptLastOnExhaustion(accept func(err error) bool) Option {
+	return func(r *Retrier) {
+		r.acceptLast = accept
+	}
+}
+
+// LifetimeExecutions caps the number of times Run executes functions over the
+// lifetime of the retrier, across runs and Reset, e.g. for clients whose
+// quota requires re-authentication. Once n executions were made, Run returns
+// ErrLifetimeExceeded, joined with the error of the last attempt if any,
+// without executing again. The count survives Reset, and clones start
+// counting from zero.
+func LifetimeExecutions(n int) Option {
+	return func(r *Retrier) {
+		r.lifetime = n
+	}
+}
+
+// MinGapBetweenRuns makes Run wait until at least d passed since the previous
+// run of the retrier completed before making its first attempt. It debounces
+// storms of back-to-back runs triggered by upstream events.
+func MinGapBetweenRuns(d time.Duration) Option {
+	return func(r *Retrier) {
+		r.minGap = d
+	}
+}
+
+// LogSummary sets a callback Run invokes exactly once when it finishes, on
+// success and failure alike, with the number of attempts made, the duration
+// of the run and its error. It suits a single wrap-up line in request logs.
+func LogSummary(fn func(attempts int, elapsed time.Duration, err error)) Option {
+	return func(r *Retrier) {
+		r.logSummary = fn
+	}
+}
+
+// Errors returns a copy of the error history recorded during the last Run.
+func (r *Retrier) Errors() []ErrorRecord {
+	return append([]ErrorRecord(nil), r.errs...)
+}
+
+// Run calls fn until it succeeds, the retrier runs out of attempts, ctx is
+// cancelled or fn fails with an error WouldRetry rejects. It returns nil on
+// success and the last error otherwise.
+//
+// When ctx ends after an attempt failed, Run returns the error of that
+// attempt by default, and ctx.Err() if PreferContextError is set. If no
+// attempt was made, ctx.Err() is returned either way.
+func (r *Retrier) Run(ctx context.Context, fn func() error) error {
+	if fn == nil {
+		return ErrNilFunc
+	}
+	return r.run(ctx, func(context.Context) error { return fn() }, false)
+}
+
+// run is Run with attempts taking a context. With bound, every attempt gets
+// one that ends with the run, see attemptContext; otherwise the contexts are
+// meant to be ignored.
+func (r *Retrier) run(ctx context.Context, fn func(ctx context.Context) error, bound bool) error {
+	began := r.now()
+	// untimed is ctx before Timeout applies, waited on for MinAttempts.
+	untimed := ctx
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+	r.deadline, _ = ctx.Deadline()
+	defer func() { r.deadline = time.Time{} }()
+
+	// Attempts get actx, or a context derived from auntimed while they are
+	// owed to MinAttempts.
+	actx, auntimed := ctx, untimed
+	if bound {
+		var stop context.CancelFunc
+		auntimed, stop = r.attemptContext(untimed)
+		defer stop()
+		actx = auntimed
+		if !r.deadline.IsZero() {
+			var cancel context.CancelFunc
+			actx, cancel = context.WithDeadline(auntimed, r.deadline)
+			defer cancel()
+		}
+	}
+
+	var (
+		err    error
+		n      int
+		exec   time.Duration
+		reason StopReason
+
+		// Cumulative execution and backoff time of the run.
+		execTotal, backoffTotal time.Duration
+	)
+	r.recorder.start(&r.recording)
+	r.usedDelays = r.usedDelays[:0]
+	r.errs = r.errs[:0]
+	r.sameErr, r.sameCount = nil, 0
+
+	if gap := r.minGap - r.since(r.lastRunEnd); r.minGap > 0 && gap > 0 {
+		if !r.sleep(ctx, gap) {
+			return r.finish(ctx, began, r.waitStopReason(ctx), nil, 0)
+		}
+	}
+
+	if r.preflight != nil {
+		if err := r.preflight(); err != nil {
+			return r.finish(ctx, began, StopPreflight, err, 0)
+		}
+	}
+
+	if r.trace && trace.IsEnabled() {
+		var task *trace.Task
+		ctx, task = trace.NewTask(ctx, "retry.Run")
+		defer task.End()
+	}
+
+	for reason == StopNone {
+		if r.lifetime > 0 && r.executions >= r.lifetime {
+			reason = StopLifetime
+			break
+		}
+
+		wctx := ctx
+		owed := n < r.minAttempts
+		if owed {
+			wctx = untimed
+			r.owedDeadline = r.deadline
+		}
+
+		start := r.now()
+		ok := r.traceWait(wctx, n)
+		r.owedDeadline = time.Time{}
+		if n > 0 {
+			var backoff time.Duration
+			if ok {
+				backoff = r.since(start)
+			}
+			backoffTotal += backoff
+			r.reportTiming(n, exec, backoff)
+		}
+		if !ok {
+			reason = r.waitStopReason(wctx)
+			break
+		}
+		if n > 0 {
+			r.recordDelay(r.slept)
+			r.emit(EventRetry, err)
+		}
+		n++
+		r.executions++
+
+		start = r.now()
+		r.rate.add(start)
+		if err = r.injectFailure(n); err == nil {
+			if owed && bound && r.Timeout > 0 {
+				// The deadline of the run may have passed already.
+				octx, cancel := context.WithTimeout(auntimed, r.Timeout)
+				err = r.traceAttempt(ctx, octx, n, fn)
+				cancel()
+			} else {
+				err = r.traceAttempt(ctx, actx, n, fn)
+			}
+		}
+		r.recordAttempt(start, err)
+		exec = r.since(start)
+		execTotal += exec
+		if err == nil && r.SlowAttemptThreshold > 0 && exec > r.SlowAttemptThreshold {
+			err = ErrSlowAttempt
+		}
+		r.observeLatency(exec)
+		r.slowDown(err)
+		r.recorder.attempt(&r.recording, r.MaxRetained, n, r.slept, err)
+		held := r.retryWhile != nil && r.retryWhile()
+		if err == nil {
+			// A success ends a streak of the same error.
+			r.sameErr, r.sameCount = nil, 0
+			if r.resetOnSuccess {
+				r.restart()
+			}
+			if !held {
+				reason = StopSuccess
+			}
+		} else {
+			r.Failed()
+			r.recordError(err)
+			r.failedAt(r.now())
+			if r.errorDelay != nil {
+				if d, ok := r.errorDelay(err); ok {
+					r.overrideNext(d)
+				}
+			}
+			switch {
+			case !r.WouldRetry(err):
+				reason = StopAbort
+			case r.tooManySame(err):
+				reason = StopSameError
+			case r.backoffRatioExceeded(execTotal, backoffTotal):
+				reason = StopBackoffRatio
+			}
+		}
+		if r.verbose != nil {
+			r.verbose("retry", reason)
+		}
+		if reason != StopNone {
+			r.reportTiming(n, exec, 0)
+		}
+	}
+
+	return r.finish(ctx, began, reason, err, n)
+}
+
+// finish wraps up a run that began at began and stopped for reason after n
+// attempts, the last of which returned last, and returns the error of the
+// run.
+func (r *Retrier) finish(ctx context.Context, began time.Time, reason StopReason, last error, n int) error {
+	if r.verbose != nil {
+		r.verbose("stop", reason)
+	}
+	var err error
+	switch reason {
+	case StopSuccess:
+	case StopPreflight:
+		err = last
+	case StopSibling:
+		err = r.abortToken.Err()
+	case StopLifetime:
+		err = ErrLifetimeExceeded
+		if last != nil {
+			err = errors.Join(err, last)
+		}
+	case StopAttempts:
+		if last != nil && r.acceptLast != nil && r.acceptLast(last) {
+			break
+		}
+		err = r.finalError(ctx, last, n)
+	default:
+		if ctx.Err() != nil && r.onCancel != nil {
+			err = r.onCancel(ctx, last)
+		} else {
+			err = r.finalError(ctx, last, n)
+		}
+		if ctx.Err() != nil && r.cleanup != nil {
+			err = errors.Join(err, r.runCleanup())
+		}
+	}
+
+	if reason == StopAbort && r.abortToken != nil {
+		r.abortToken.Abort(err)
+	}
+	r.lastRunEnd = r.now()
+	r.won = reason == StopSuccess
+	r.winner = Winner{Attempt: n}
+	r.recordStop(ctx, reason, last, n)
+	r.recorder.finish(&r.recording, err)
+	if r.logSummary != nil {
+		r.logSummary(n, r.since(began), err)
+	}
+	if reason == StopSuccess {
+		r.emit(EventSuccess, nil)
+	} else {
+		r.emit(EventGiveUp, err)
+	}
+	return err
+}
+
+// RunUntil is like Run, but also stops retrying once deadline passes. A sleep
+// that would overshoot deadline is shortened to end at it, so the last attempt
+// is made right at the deadline. Attempts and the other limits of r still
+// apply, whichever comes first ends the run.
+func (r *Retrier) RunUntil(ctx context.Context, deadline time.Time, fn func() error) error {
+	r.until = deadline
+	defer func() { r.until = time.Time{} }()
+	return r.Run(ctx, fn)
+}
+
+// RunWithDeadlines is like Run, but attempt i gets a context with a timeout of
+// deadlines[i]. Attempts beyond the end of deadlines reuse its last element.
+// An empty slice sets no timeouts.
+func (r *Retrier) RunWithDeadlines(ctx context.Context, deadlines []time.Duration, fn func(ctx context.Context) error) error {
+	if fn == nil {
+		return ErrNilFunc
+	}
+
+	var i int
+	return r.run(ctx, func(ctx context.Context) error {
+		if len(deadlines) == 0 {
+			return fn(ctx)
+		}
+
+		d := deadlines[len(deadlines)-1]
+		if i < len(deadlines) {
+			d = deadlines[i]
+		}
+		i++
+
+		actx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+		return fn(actx)
+	}, true)
+}
+
+// attemptContext returns a context derived from ctx for the attempts of a run
+// that also ends at the deadline of RunUntil and when the retrier is closed or
+// aborted, so that an attempt blocked on it doesn't outlive the run.
+func (r *Retrier) attemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	var cancel context.CancelFunc
+	if r.until.IsZero() {
+		ctx, cancel = context.WithCancel(ctx)
+	} else {
+		ctx, cancel = context.WithDeadline(ctx, r.until)
+	}
+
+	closed, aborted := r.closed.done(), r.abortToken.done()
+	if closed == nil && aborted == nil {
+		return ctx, cancel
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-closed:
+			cancel()
+		case <-aborted:
+			cancel()
+		}
+	}()
+	return ctx, cancel
+}
+
+// restart makes the backoff start over from the bottom of the curve.
+// Unlike Reset, it keeps the rest of the runtime state.
+func (r *Retrier) restart() {
+	r.Delay = r.anchor()
+	r.capped = false
+}
+
+// failedAt notes a failure at t, restarting the backoff if the previous one
+// was longer than the flap window ago.
+func (r *Retrier) failedAt(t time.Time) {
+	if r.flapWindow <= 0 {
+		return
+	}
+	if !r.lastFailure.IsZero() && t.Sub(r.lastFailure) > r.flapWindow {
+		r.restart()
+	}
+	r.lastFailure = t
+}
+
+// backoffRatioExceeded reports whether the time spent backing off exceeds
+// MaxBackoffRatio times the time spent executing attempts.
+func (r *Retrier) backoffRatioExceeded(exec, backoff time.Duration) bool {
+	return r.MaxBackoffRatio > 0 && float64(backoff) > r.MaxBackoffRatio*float64(exec)
+}
+
+func (r *Retrier) reportTiming(attempt int, exec, backoff time.Duration) {
+	if r.onTiming != nil {
+		r.onTiming(attempt, exec, backoff)
+	}
+}
+
+func (r *Retrier) runCleanup() error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cleanupGrace)
+	defer cancel()
+	return r.cleanup(ctx)
+}
+
+// finalError returns the error Run reports when it gives up after n attempts,
+// the last of which returned last.
+func (r *Retrier) finalError(ctx context.Context, last error, n int) error {
+	if err := ctx.Err(); err != nil && (last == nil || r.preferCtxErr) {
+		return err
+	}
+	if last == nil {
+		if n == 0 {
+			return ErrNoAttempts
+		}
+		return ErrConditionHeld
+	}
+	if r.recordErrors {
+		return r.joinErrors()
+	}
+	return last
+}
+
+// Wrap returns a function that runs fn under the policy of r.
+// Every call uses its own clone of r, so calls do not share backoff state.
+func Wrap(r *Retrier, fn func() error) func(context.Context) error {
+	return func(ctx context.Context) error {
+		return r.Clone().Run(ctx, fn)
+	}
+}
+
+func (r *Retrier) recordError(err error) {
+	if !r.recordErrors {
+		return
+	}
+	if n := len(r.errs); r.coalesceErrors && n > 0 && sameError(r.errs[n-1].Err, err) {
+		r.errs[n-1].Count++
+		return
+	}
+	r.errs = retain(append(r.errs, ErrorRecord{Err: err, Count: 1}), r.MaxRetained)
+}
+
+// recordAttempt remembers an attempt that started at start, if KeepRecent is
+// set.
+func (r *Retrier) recordAttempt(start time.Time, err error) {
+	if r.recent == nil {
+		return
+	}
+	rec := AttemptRecord{At: start, Delay: r.slept}
+	if err != nil {
+		rec.Err = err.Error()
+	}
+	r.recent.add(rec)
+}
+
+// defaultRetainedDelays bounds the delays kept for UsedDelays when MaxRetained
+// is not set, as they are recorded for every run.
+const defaultRetainedDelays = 1024
+
+func (r *Retrier) recordDelay(d time.Duration) {
+	n := r.MaxRetained
+	if n <= 0 {
+		n = defaultRetainedDelays
+	}
+	r.usedDelays = retain(append(r.usedDelays, d), n)
+}
+
+// retain drops the oldest entries of s beyond the last n, reusing its backing
+// array. It is a no-op when n is not positive.
+func retain[T any](s []T, n int) []T {
+	if n <= 0 || len(s) <= n {
+		return s
+	}
+	return append(s[:0], s[len(s)-n:]...)
+}
+
+// tooManySame reports whether err completes a streak of MaxSameError
+// identical errors.
+func (r *Retrier) tooManySame(err error) bool {
+	if r.MaxSameError <= 0 {
+		return false
+	}
+	if r.sameErr != nil && sameError(r.sameErr, err) {
+		r.sameCount++
+	} else {
+		r.sameErr, r.sameCount = err, 1
+	}
+	return r.sameCount >= r.MaxSameError
+}
+
+func (r *Retrier) joinErrors() error {
+	errs := make([]error, 0, len(r.errs))
+	for _, rec := range r.errs {
+		if rec.Count > 1 {
+			errs = append(errs, fmt.Errorf("%w (repeated %d times)", rec.Err, rec.Count))
+			continue
+		}
+		errs = append(errs, rec.Err)
+	}
+	return errors.Join(errs...)
+}
+
+// sameError reports whether a and b should be treated as the same error.
+// They are the same if errors.Is(a, b) holds or their messages are equal.
+func sameError(a, b error) bool {
+	return errors.Is(a, b) || a.Error() == b.Error()
+}
+
+// injectFailure returns the failure ChaosHook injects into attempt n, if any.
+func (r *Retrier) injectFailure(n int) error {
+	if r.chaos == nil {
+		return nil
+	}
+	return r.chaos(n)
+}
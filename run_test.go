@@ -0,0 +1,1031 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(time.Millisecond, time.Millisecond)
+
+	var calls int
+	err := r.Run(ctx, func() error {
+		calls++
+		if calls < 3 {
+			return io.EOF
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3", calls)
+	}
+}
+
+func TestRun_Exhausted(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(time.Millisecond, time.Millisecond, Attempts(3))
+
+	err := r.Run(ctx, func() error { return io.EOF })
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = New(time.Millisecond, time.Millisecond, Attempts(0)).Run(ctx, func() error { return nil })
+	if !errors.Is(err, ErrNoAttempts) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCoalesceErrors(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(0, 0, Attempts(500), CoalesceErrors())
+
+	var calls int
+	err := r.Run(ctx, func() error {
+		calls++
+		if calls == 250 {
+			return io.ErrUnexpectedEOF
+		}
+		return io.EOF
+	})
+
+	hist := r.Errors()
+	if len(hist) != 3 {
+		t.Fatalf("history has %d entries, want 3: %v", len(hist), hist)
+	}
+	for i, want := range []ErrorRecord{
+		{Err: io.EOF, Count: 249},
+		{Err: io.ErrUnexpectedEOF, Count: 1},
+		{Err: io.EOF, Count: 250},
+	} {
+		if hist[i] != want {
+			t.Fatalf("entry %d = %v, want %v", i, hist[i], want)
+		}
+	}
+
+	if !errors.Is(err, io.EOF) || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("joined error lost members: %v", err)
+	}
+	if n := strings.Count(err.Error(), "\n") + 1; n != 3 {
+		t.Fatalf("joined error has %d lines, want 3: %v", n, err)
+	}
+
+	r.Reset()
+	if len(r.Errors()) != 0 {
+		t.Fatalf("history not cleared by Reset")
+	}
+}
+
+func TestRecordErrors(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(0, 0, Attempts(5), RecordErrors())
+	_ = r.Run(ctx, func() error { return io.EOF })
+
+	if n := len(r.Errors()); n != 5 {
+		t.Fatalf("history has %d entries, want 5", n)
+	}
+
+	// Every run starts a history of its own.
+	stop := errors.New("stop")
+	r = New(0, 0, RecordErrors(), AbortOn(stop))
+	for _, first := range []error{io.EOF, io.ErrUnexpectedEOF} {
+		var calls int
+		err := r.Run(ctx, func() error {
+			calls++
+			if calls == 1 {
+				return first
+			}
+			return stop
+		})
+		if n := len(r.Errors()); n != 2 || r.Errors()[0].Err != first {
+			t.Fatalf("history of the run is %+v, want %v and %v", r.Errors(), first, stop)
+		}
+		if errors.Is(err, io.EOF) != (first == io.EOF) {
+			t.Fatalf("error of the run %v includes errors of an earlier run", err)
+		}
+	}
+}
+
+func TestMaxSameError(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(0, 0, MaxSameError(3))
+
+	var calls int
+	err := r.Run(ctx, func() error {
+		calls++
+		return io.EOF
+	})
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3", calls)
+	}
+}
+
+func TestMaxSameError_Streak(t *testing.T) {
+	ctx := context.Background()
+
+	// A, A, success: the next run starts a streak of its own.
+	r := New(0, 0, MaxSameError(3))
+	var calls int
+	_ = r.Run(ctx, func() error {
+		calls++
+		if calls == 3 {
+			return nil
+		}
+		return io.EOF
+	})
+	calls = 0
+	_ = r.Run(ctx, func() error {
+		calls++
+		return io.EOF
+	})
+	if calls != 3 {
+		t.Fatalf("streak carried over into the next run: calls = %d, want 3", calls)
+	}
+
+	// A success the run goes on after ends the streak as well.
+	r = New(0, 0, MaxSameError(3), RetryWhile(func() bool { return true }))
+	calls = 0
+	_ = r.Run(ctx, func() error {
+		calls++
+		if calls == 3 {
+			return nil
+		}
+		return io.EOF
+	})
+	if calls != 6 {
+		t.Fatalf("streak survived a success: calls = %d, want 6", calls)
+	}
+}
+
+func TestMaxSameError_Alternating(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(0, 0, Attempts(10), MaxSameError(2))
+
+	var calls int
+	_ = r.Run(ctx, func() error {
+		calls++
+		if calls%2 == 0 {
+			return io.ErrUnexpectedEOF
+		}
+		// Same message, different value.
+		return errors.New("EOF")
+	})
+	if calls != 10 {
+		t.Fatalf("calls = %d, want 10", calls)
+	}
+
+	calls = 0
+	r = New(0, 0, Attempts(10), MaxSameError(2))
+	_ = r.Run(ctx, func() error {
+		calls++
+		if calls == 1 {
+			return io.EOF
+		}
+		return errors.New("EOF")
+	})
+	if calls != 2 {
+		t.Fatalf("errors with equal messages not treated as identical: calls = %d", calls)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(time.Millisecond, 10*time.Millisecond, Attempts(3), Rate(2))
+
+	var calls int
+	wrapped := Wrap(r, func() error {
+		calls++
+		return io.EOF
+	})
+
+	for i := 0; i < 2; i++ {
+		calls = 0
+		if err := wrapped(ctx); !errors.Is(err, io.EOF) {
+			t.Fatalf("call %d: unexpected error: %v", i, err)
+		}
+		if calls != 3 {
+			t.Fatalf("call %d: calls = %d, want 3", i, calls)
+		}
+	}
+
+	if r.Attempts != 3 || r.Delay != 0 {
+		t.Fatalf("wrapped calls changed the template: attempts %d, delay %v", r.Attempts, r.Delay)
+	}
+}
+
+func TestRetryWhile(t *testing.T) {
+	ctx := context.Background()
+
+	var checks int
+	r := New(0, 0, RetryWhile(func() bool {
+		checks++
+		return checks < 4
+	}))
+
+	var calls int
+	err := r.Run(ctx, func() error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 4 {
+		t.Fatalf("calls = %d, want 4", calls)
+	}
+}
+
+func TestRetryWhile_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	// Once the condition turns false, errors are retried as usual.
+	r := New(0, 0, RetryWhile(func() bool { return false }))
+
+	var calls int
+	err := r.Run(ctx, func() error {
+		calls++
+		if calls < 3 {
+			return io.EOF
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("err = %v, calls = %d; want nil, 3", err, calls)
+	}
+
+	r = New(0, 0, Attempts(3), RetryWhile(func() bool { return true }))
+	err = r.Run(ctx, func() error { return nil })
+	if !errors.Is(err, ErrConditionHeld) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCleanupOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var cleanups int
+	errCleanup := errors.New("flush failed")
+	r := New(time.Millisecond, time.Millisecond, CleanupOnCancel(func(ctx context.Context) error {
+		cleanups++
+		if ctx.Err() != nil {
+			t.Errorf("cleanup context already done: %v", ctx.Err())
+		}
+		deadline, ok := ctx.Deadline()
+		if !ok || time.Until(deadline) > time.Second {
+			t.Errorf("cleanup context not bounded by grace: %v, %v", deadline, ok)
+		}
+		return errCleanup
+	}, time.Second))
+
+	var calls int
+	err := r.Run(ctx, func() error {
+		calls++
+		if calls == 2 {
+			cancel()
+		}
+		return io.EOF
+	})
+	if cleanups != 1 {
+		t.Fatalf("cleanup ran %d times, want 1", cleanups)
+	}
+	if !errors.Is(err, errCleanup) || !errors.Is(err, io.EOF) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// No cleanup without cancellation.
+	cleanups = 0
+	r.Reset()
+	_ = r.Run(context.Background(), func() error { return nil })
+	if cleanups != 0 {
+		t.Fatalf("cleanup ran without cancellation")
+	}
+}
+
+func TestMaxRetained(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(0, 0, Attempts(100000), RecordErrors(), MaxRetained(10))
+
+	var calls int
+	_ = r.Run(ctx, func() error {
+		calls++
+		return fmt.Errorf("attempt %d", calls)
+	})
+
+	hist := r.Errors()
+	if len(hist) != 10 {
+		t.Fatalf("history has %d entries, want 10", len(hist))
+	}
+	if cap(hist) > 64 {
+		t.Fatalf("history backing array grew to %d", cap(hist))
+	}
+	if got := hist[9].Err.Error(); got != "attempt 100000" {
+		t.Fatalf("last entry = %q, want most recent error", got)
+	}
+	if got := hist[0].Err.Error(); got != "attempt 99991" {
+		t.Fatalf("first entry = %q, want oldest retained error", got)
+	}
+}
+
+func TestOnAttemptTiming(t *testing.T) {
+	ctx := context.Background()
+
+	type timing struct {
+		attempt       int
+		exec, backoff time.Duration
+	}
+	var got []timing
+
+	r := New(10*time.Millisecond, 10*time.Millisecond, OnAttemptTiming(func(attempt int, exec, backoff time.Duration) {
+		got = append(got, timing{attempt, exec, backoff})
+	}))
+
+	var calls int
+	_ = r.Run(ctx, func() error {
+		calls++
+		time.Sleep(5 * time.Millisecond)
+		if calls < 3 {
+			return io.EOF
+		}
+		return nil
+	})
+
+	if len(got) != 3 {
+		t.Fatalf("got %d timings, want 3: %v", len(got), got)
+	}
+	for i, tm := range got {
+		if tm.attempt != i+1 {
+			t.Fatalf("timing %d for attempt %d", i, tm.attempt)
+		}
+		if tm.exec < 5*time.Millisecond {
+			t.Fatalf("attempt %d: execution %v shorter than the function", tm.attempt, tm.exec)
+		}
+	}
+	for _, tm := range got[:2] {
+		if tm.backoff < 10*time.Millisecond {
+			t.Fatalf("attempt %d: backoff %v shorter than the delay", tm.attempt, tm.backoff)
+		}
+	}
+	if got[2].backoff != 0 {
+		t.Fatalf("final attempt reported backoff %v", got[2].backoff)
+	}
+}
+
+func TestPreferContextError(t *testing.T) {
+	for _, prefer := range []bool{false, true} {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		r := New(time.Millisecond, time.Millisecond, PreferContextError(prefer))
+		err := r.Run(ctx, func() error {
+			cancel()
+			return io.EOF
+		})
+
+		want := io.EOF
+		if prefer {
+			want = context.Canceled
+		}
+		if err != want {
+			t.Fatalf("prefer %v: err = %v, want %v", prefer, err, want)
+		}
+	}
+}
+
+func TestOnCancel(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		choose func(ctx context.Context, lastErr error) error
+		want   []error
+	}{
+		{"context", func(ctx context.Context, _ error) error { return ctx.Err() }, []error{context.Canceled}},
+		{"last", func(_ context.Context, lastErr error) error { return lastErr }, []error{io.EOF}},
+		{"merged", func(ctx context.Context, lastErr error) error {
+			return errors.Join(ctx.Err(), lastErr)
+		}, []error{context.Canceled, io.EOF}},
+	} {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		// The hook takes precedence over PreferContextError.
+		r := New(time.Millisecond, time.Millisecond, OnCancel(tt.choose), PreferContextError(true))
+		err := r.Run(ctx, func() error {
+			cancel()
+			return io.EOF
+		})
+		for _, want := range tt.want {
+			if !errors.Is(err, want) {
+				t.Fatalf("%s: err = %v, want %v", tt.name, err, want)
+			}
+		}
+		if tt.name == "last" && errors.Is(err, context.Canceled) {
+			t.Fatalf("%s: err = %v, want only %v", tt.name, err, io.EOF)
+		}
+	}
+
+	r := New(0, 0, Attempts(1), OnCancel(func(context.Context, error) error {
+		t.Fatalf("hook called without cancellation")
+		return nil
+	}))
+	if err := r.Run(context.Background(), func() error { return io.EOF }); err != io.EOF {
+		t.Fatalf("err = %v, want %v", err, io.EOF)
+	}
+}
+
+func TestRunWithDeadlines(t *testing.T) {
+	ctx := context.Background()
+
+	deadlines := []time.Duration{time.Second, time.Minute}
+	want := []time.Duration{time.Second, time.Minute, time.Minute, time.Minute}
+
+	r := New(0, 0, Attempts(len(want)))
+
+	var i int
+	_ = r.RunWithDeadlines(ctx, deadlines, func(ctx context.Context) error {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			t.Fatalf("attempt %d has no deadline", i)
+		}
+		if left := time.Until(deadline); left > want[i] || left < want[i]-time.Second/2 {
+			t.Fatalf("attempt %d: deadline in %v, want %v", i, left, want[i])
+		}
+		i++
+		return io.EOF
+	})
+	if i != len(want) {
+		t.Fatalf("made %d attempts, want %d", i, len(want))
+	}
+}
+
+func TestMaxBackoffRatio(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(20*time.Millisecond, 20*time.Millisecond, MaxBackoffRatio(5))
+
+	// Every attempt takes about 1ms while every backoff takes 20ms, so the
+	// first backoff already exceeds the ratio once the second attempt failed.
+	var calls int
+	err := r.Run(ctx, func() error {
+		calls++
+		time.Sleep(time.Millisecond)
+		return io.EOF
+	})
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+
+	// A slow function stays within the ratio.
+	calls = 0
+	r = New(time.Millisecond, time.Millisecond, Attempts(4), MaxBackoffRatio(10))
+	_ = r.Run(ctx, func() error {
+		calls++
+		time.Sleep(time.Millisecond)
+		return io.EOF
+	})
+	if calls != 4 {
+		t.Fatalf("calls = %d, want 4", calls)
+	}
+}
+
+func TestFlapReset(t *testing.T) {
+	const window = time.Minute
+
+	r := New(time.Millisecond, time.Second, Rate(2), FlapReset(window))
+	r.Delay = 64 * time.Millisecond
+
+	now := time.Now()
+
+	// Clustered failures keep backing off.
+	r.failedAt(now)
+	r.failedAt(now.Add(window / 2))
+	if r.Delay != 64*time.Millisecond {
+		t.Fatalf("clustered failures reset the delay to %v", r.Delay)
+	}
+
+	// A failure after a healthy period starts over.
+	r.failedAt(now.Add(2 * window))
+	if r.Delay != time.Millisecond {
+		t.Fatalf("spaced failure did not reset the delay: %v", r.Delay)
+	}
+}
+
+func TestFlapReset_Run(t *testing.T) {
+	ctx := context.Background()
+
+	failTwice := func() func() error {
+		var calls int
+		return func() error {
+			calls++
+			if calls <= 2 {
+				return io.EOF
+			}
+			return nil
+		}
+	}
+
+	// Two runs separated by a healthy period, with and without flap detection.
+	flap := New(time.Millisecond, time.Second, Rate(2), FlapReset(50*time.Millisecond))
+	plain := New(time.Millisecond, time.Second, Rate(2))
+	for _, r := range []*Retrier{flap, plain} {
+		_ = r.Run(ctx, failTwice())
+		time.Sleep(100 * time.Millisecond)
+		_ = r.Run(ctx, failTwice())
+	}
+
+	if flap.Delay >= plain.Delay {
+		t.Fatalf("backoff not reset after a healthy period: %v, without reset %v", flap.Delay, plain.Delay)
+	}
+}
+
+func TestResetOnSuccess(t *testing.T) {
+	ctx := context.Background()
+
+	var rounds int
+	r := New(time.Millisecond, time.Second, Rate(2), ResetOnSuccess(), RetryWhile(func() bool {
+		rounds++
+		return rounds < 8
+	}))
+
+	// Failures and successes alternate, so the delay never grows past the
+	// step following a single failure.
+	var maxDelay time.Duration
+	var calls int
+	_ = r.Run(ctx, func() error {
+		calls++
+		if r.slept > maxDelay {
+			maxDelay = r.slept
+		}
+		if calls%2 == 1 {
+			return io.EOF
+		}
+		return nil
+	})
+	if maxDelay != 4*time.Millisecond {
+		t.Fatalf("max delay %v, want 4ms", maxDelay)
+	}
+}
+
+func TestSlowAttemptThreshold(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(0, 0, SlowAttemptThreshold(5*time.Millisecond))
+
+	var calls int
+	err := r.Run(ctx, func() error {
+		calls++
+		if calls < 3 {
+			time.Sleep(10 * time.Millisecond)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3", calls)
+	}
+
+	r = New(0, 0, Attempts(2), SlowAttemptThreshold(time.Millisecond))
+	err = r.Run(ctx, func() error {
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	})
+	if !errors.Is(err, ErrSlowAttempt) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type quotaError struct {
+	reset time.Duration
+}
+
+func (e quotaError) Error() string { return "quota exceeded" }
+
+func TestErrorDelayFunc(t *testing.T) {
+	ctx := context.Background()
+
+	var slept []time.Duration
+	r := New(time.Millisecond, 50*time.Millisecond, Rate(2),
+		ErrorDelayFunc(func(err error) (time.Duration, bool) {
+			var q quotaError
+			if errors.As(err, &q) {
+				return q.reset, true
+			}
+			return 0, false
+		}),
+	)
+
+	errs := []error{
+		quotaError{reset: 7 * time.Millisecond},
+		io.EOF,
+		quotaError{reset: time.Hour},
+		nil,
+	}
+	var i int
+	_ = r.Run(ctx, func() error {
+		slept = append(slept, r.slept)
+		err := errs[i]
+		i++
+		return err
+	})
+
+	want := []time.Duration{
+		0,
+		7 * time.Millisecond,  // dictated by the quota error
+		4 * time.Millisecond,  // the curve kept advancing underneath
+		50 * time.Millisecond, // clamped to Ceil
+	}
+	for i := range want {
+		if slept[i] != want[i] {
+			t.Fatalf("delay before attempt %d = %v, want %v", i+1, slept[i], want[i])
+		}
+	}
+}
+
+func TestUsedDelays(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(2*time.Millisecond, 20*time.Millisecond, Jitter(0.3), Attempts(5))
+
+	var (
+		observed []time.Duration
+		last     time.Time
+	)
+	_ = r.Run(ctx, func() error {
+		if !last.IsZero() {
+			observed = append(observed, time.Since(last))
+		}
+		last = time.Now()
+		return io.EOF
+	})
+
+	used := r.UsedDelays()
+	if len(used) != 4 {
+		t.Fatalf("recorded %d delays, want 4", len(used))
+	}
+	for i, d := range used {
+		if observed[i] < d || observed[i] > d+10*time.Millisecond {
+			t.Fatalf("delay %d recorded as %v, observed %v", i, d, observed[i])
+		}
+	}
+}
+
+func TestUsedDelays_Bounded(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(0, 0, Attempts(5000))
+	_ = r.Run(ctx, func() error { return io.EOF })
+	if n := len(r.UsedDelays()); n != defaultRetainedDelays {
+		t.Fatalf("recorded %d delays, want %d", n, defaultRetainedDelays)
+	}
+
+	r = New(0, 0, Attempts(50), MaxRetained(10))
+	_ = r.Run(ctx, func() error { return io.EOF })
+	if n := len(r.UsedDelays()); n != 10 {
+		t.Fatalf("recorded %d delays, want 10", n)
+	}
+}
+
+func TestUsedDelays_Copy(t *testing.T) {
+	ctx := context.Background()
+
+	schedule := func(ctx context.Context, d time.Duration) error { return nil }
+	r := New(time.Second, time.Hour, Rate(2), Jitter(0), Attempts(3), RecordErrors(), WithScheduler(schedule))
+	_ = r.Run(ctx, func() error { return io.EOF })
+	delays, errs := r.UsedDelays(), r.Errors()
+
+	r.Attempts = 3
+	_ = r.Run(ctx, func() error { return io.ErrUnexpectedEOF })
+	if delays[0] != 2*time.Second || delays[1] != 4*time.Second {
+		t.Fatalf("delays of the first run rewritten to %v", delays)
+	}
+	for _, e := range errs {
+		if e.Err != io.EOF {
+			t.Fatalf("errors of the first run rewritten to %+v", errs)
+		}
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	ctx := context.Background()
+
+	errInvalid := errors.New("invalid request")
+	r := New(0, 0, Preflight(func() error { return errInvalid }))
+
+	err := r.Run(ctx, func() error {
+		t.Fatalf("fn called after failed preflight")
+		return nil
+	})
+	if err != errInvalid {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Attempts != -1 || r.attempt != 0 {
+		t.Fatalf("failed preflight consumed attempts")
+	}
+
+	var checks, calls int
+	r = New(0, 0, Preflight(func() error {
+		checks++
+		return nil
+	}))
+	_ = r.Run(ctx, func() error {
+		calls++
+		if calls < 3 {
+			return io.EOF
+		}
+		return nil
+	})
+	if checks != 1 || calls != 3 {
+		t.Fatalf("checks = %d, calls = %d; want 1, 3", checks, calls)
+	}
+}
+
+func TestLogSummary(t *testing.T) {
+	ctx := context.Background()
+
+	type summary struct {
+		attempts int
+		elapsed  time.Duration
+		err      error
+	}
+	var got []summary
+	logSummary := LogSummary(func(attempts int, elapsed time.Duration, err error) {
+		got = append(got, summary{attempts, elapsed, err})
+	})
+
+	r := New(5*time.Millisecond, 5*time.Millisecond, logSummary)
+	var calls int
+	_ = r.Run(ctx, func() error {
+		calls++
+		if calls < 3 {
+			return io.EOF
+		}
+		return nil
+	})
+	if len(got) != 1 {
+		t.Fatalf("summary logged %d times, want once", len(got))
+	}
+	if s := got[0]; s.attempts != 3 || s.err != nil || s.elapsed < 10*time.Millisecond {
+		t.Fatalf("unexpected success summary: %+v", s)
+	}
+
+	got = nil
+	r = New(0, 0, Attempts(5), logSummary)
+	_ = r.Run(ctx, func() error { return io.EOF })
+	if len(got) != 1 {
+		t.Fatalf("summary logged %d times, want once", len(got))
+	}
+	if s := got[0]; s.attempts != 5 || s.err != io.EOF {
+		t.Fatalf("unexpected failure summary: %+v", s)
+	}
+}
+
+func TestAcceptLastOnExhaustion(t *testing.T) {
+	ctx := context.Background()
+	tolerable := AcceptLastOnExhaustion(func(err error) bool {
+		return errors.Is(err, io.EOF)
+	})
+
+	r := New(0, 0, Attempts(3), tolerable)
+	var calls int
+	err := r.Run(ctx, func() error {
+		calls++
+		return io.EOF
+	})
+	if err != nil {
+		t.Fatalf("got %v, want tolerable error accepted", err)
+	}
+	if calls != 3 {
+		t.Fatalf("got %d attempts, want 3", calls)
+	}
+
+	r = New(0, 0, Attempts(3), tolerable)
+	if err := r.Run(ctx, func() error { return io.ErrUnexpectedEOF }); err != io.ErrUnexpectedEOF {
+		t.Fatalf("got %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestRunUntil(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(time.Millisecond, time.Millisecond)
+	var calls int
+	err := r.RunUntil(ctx, time.Now().Add(time.Second), func() error {
+		calls++
+		if calls < 3 {
+			return io.EOF
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("got %v after %d calls, want success after 3", err, calls)
+	}
+
+	r = New(20*time.Millisecond, 20*time.Millisecond, TraceStop())
+	deadline := time.Now().Add(50 * time.Millisecond)
+	var last time.Time
+	calls = 0
+	err = r.RunUntil(ctx, deadline, func() error {
+		calls++
+		last = time.Now()
+		return io.EOF
+	})
+	if err != io.EOF {
+		t.Fatalf("got %v, want %v", err, io.EOF)
+	}
+	if tr := r.StopTrace(); tr.Reason != StopTimeout {
+		t.Fatalf("got stop reason %v, want %v", tr.Reason, StopTimeout)
+	}
+	// Attempts at 0, 20 and 40ms; the last sleep is shortened to 10ms.
+	if calls != 4 {
+		t.Fatalf("got %d calls, want 4", calls)
+	}
+	if d := last.Sub(deadline); d < 0 || d > 15*time.Millisecond {
+		t.Fatalf("last attempt %v off the deadline", d)
+	}
+}
+
+func TestRunUntil_Attempts(t *testing.T) {
+	r := New(0, 0, Attempts(2))
+	var calls int
+	err := r.RunUntil(context.Background(), time.Now().Add(time.Hour), func() error {
+		calls++
+		return io.EOF
+	})
+	if err != io.EOF || calls != 2 {
+		t.Fatalf("got %v after %d calls, want %v after 2", err, calls, io.EOF)
+	}
+}
+
+func TestMinGapBetweenRuns(t *testing.T) {
+	ctx := context.Background()
+
+	const gap = 50 * time.Millisecond
+	r := New(0, 0, MinGapBetweenRuns(gap))
+	var starts []time.Time
+	fn := func() error {
+		starts = append(starts, time.Now())
+		return nil
+	}
+
+	tt := time.Now()
+	_ = r.Run(ctx, fn)
+	if time.Since(tt) > gap/2 {
+		t.Fatalf("first run waited for a gap")
+	}
+	_ = r.Run(ctx, fn)
+	if d := starts[1].Sub(starts[0]); d < gap {
+		t.Fatalf("second run started %v after the first, want at least %v", d, gap)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := r.Run(ctx, fn); err != context.Canceled {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestLifetimeExecutions(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(0, 0, LifetimeExecutions(5))
+	var calls int
+	fn := func() error {
+		calls++
+		if calls%3 != 0 {
+			return io.EOF
+		}
+		return nil
+	}
+
+	if err := r.Run(ctx, fn); err != nil || calls != 3 {
+		t.Fatalf("got %v after %d calls, want success after 3", err, calls)
+	}
+	r.Reset()
+
+	err := r.Run(ctx, fn)
+	if !errors.Is(err, ErrLifetimeExceeded) || !errors.Is(err, io.EOF) {
+		t.Fatalf("got %v, want %v joined with %v", err, ErrLifetimeExceeded, io.EOF)
+	}
+	if calls != 5 {
+		t.Fatalf("got %d calls, want 5", calls)
+	}
+	r.Reset()
+
+	if err := r.Run(ctx, fn); err != ErrLifetimeExceeded {
+		t.Fatalf("got %v, want %v", err, ErrLifetimeExceeded)
+	}
+	if calls != 5 {
+		t.Fatalf("executed beyond the lifetime cap: %d calls", calls)
+	}
+
+	if err := r.Clone().Run(ctx, fn); err != nil {
+		t.Fatalf("clone inherited the used executions: %v", err)
+	}
+}
+
+func TestMinAttempts(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(10*time.Millisecond, 10*time.Millisecond, Timeout(time.Millisecond), MinAttempts(3), TraceStop())
+	var calls int
+	err := r.Run(ctx, func() error {
+		calls++
+		return io.EOF
+	})
+	if err != io.EOF {
+		t.Fatalf("got %v, want %v", err, io.EOF)
+	}
+	if calls != 3 {
+		t.Fatalf("got %d attempts, want 3", calls)
+	}
+	if tr := r.StopTrace(); tr.Reason != StopTimeout {
+		t.Fatalf("got stop reason %v, want %v", tr.Reason, StopTimeout)
+	}
+
+	r = New(0, 0, Attempts(2), MinAttempts(5))
+	calls = 0
+	_ = r.Run(ctx, func() error {
+		calls++
+		return io.EOF
+	})
+	if calls != 2 {
+		t.Fatalf("got %d attempts, want Attempts to win", calls)
+	}
+}
+
+func TestMinAttempts_Bounded(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(time.Minute, time.Minute, Timeout(20*time.Millisecond), MinAttempts(3))
+	var calls int
+	start := time.Now()
+	err := r.RunWithDeadlines(ctx, nil, func(ctx context.Context) error {
+		calls++
+		if _, ok := ctx.Deadline(); !ok {
+			t.Errorf("attempt %d has no deadline", calls)
+		}
+		<-ctx.Done()
+		return io.EOF
+	})
+	if err != io.EOF {
+		t.Fatalf("got %v, want %v", err, io.EOF)
+	}
+	if calls != 3 {
+		t.Fatalf("got %d attempts, want 3", calls)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("run overran its timeout by %v", took)
+	}
+}
+
+func TestWithClock_WallClockStep(t *testing.T) {
+	ctx := context.Background()
+
+	// A wall clock without monotonic readings, stepped back by NTP below.
+	var step time.Duration
+	clock := func() time.Time { return time.Now().Round(0).Add(step) }
+
+	var elapsed []time.Duration
+	r := New(0, 0, WithClock(clock), MinGapBetweenRuns(20*time.Millisecond),
+		LogSummary(func(_ int, d time.Duration, _ error) { elapsed = append(elapsed, d) }))
+
+	_ = r.Run(ctx, func() error {
+		step = -time.Hour
+		return nil
+	})
+
+	// Step back again after the end of the first run was noted.
+	step = -2 * time.Hour
+	tt := time.Now()
+	_ = r.Run(ctx, func() error { return nil })
+	if d := time.Since(tt); d > time.Second {
+		t.Fatalf("clock step made the gap between runs last %v", d)
+	}
+	for _, d := range elapsed {
+		if d < 0 || d > time.Second {
+			t.Fatalf("clock step skewed elapsed times: %v", elapsed)
+		}
+	}
+}
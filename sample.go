@@ -0,0 +1,30 @@
+package retry
+
+import (
+	"math/rand"
+	"time"
+)
+
+// SampleDelays returns samples independent schedules of the delays slept
+// before each of the first attempts attempts of a run, without sleeping. It
+// suits load generators modelling the retry timing of many clients.
+//
+// The schedules are jittered by generators derived from the seed of r, so
+// they are reproducible with WithSeed. r itself is not changed.
+func (r *Retrier) SampleDelays(attempts, samples int) [][]time.Duration {
+	seed := r.Seed()
+
+	out := make([][]time.Duration, samples)
+	for i := range out {
+		sim := r.Clone()
+		sim.src = rand.New(rand.NewSource(int64(splitmix64(uint64(seed) + uint64(i)))))
+
+		out[i] = make([]time.Duration, attempts)
+		for j := range out[i] {
+			sim.Delay = sim.next()
+			out[i][j] = sim.Delay
+			sim.advance(sim.Delay)
+		}
+	}
+	return out
+}
@@ -0,0 +1,57 @@
+package retry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSampleDelays(t *testing.T) {
+	r := New(time.Millisecond, time.Second, Rate(2), Jitter(0.2))
+
+	got := r.SampleDelays(5, 20)
+	if len(got) != 20 {
+		t.Fatalf("got %d samples, want 20", len(got))
+	}
+	for i, s := range got {
+		if len(s) != 5 {
+			t.Fatalf("sample %d has %d delays, want 5", i, len(s))
+		}
+		if s[0] != 0 {
+			t.Fatalf("sample %d: first delay %v, want 0", i, s[0])
+		}
+	}
+
+	distinct := make(map[time.Duration]bool)
+	for _, s := range got {
+		distinct[s[3]] = true
+	}
+	if len(distinct) < 15 {
+		t.Fatalf("samples not jittered: %d distinct delays out of 20", len(distinct))
+	}
+
+	if r.Delay != 0 || r.attempt != 0 {
+		t.Fatalf("SampleDelays changed the retrier: delay %v, attempt %d", r.Delay, r.attempt)
+	}
+
+	again := New(time.Millisecond, time.Second, Rate(2), Jitter(0.2), WithSeed(r.Seed())).SampleDelays(5, 20)
+	for i := range got {
+		for j := range got[i] {
+			if got[i][j] != again[i][j] {
+				t.Fatalf("sample %d delay %d not reproducible: %v != %v", i, j, got[i][j], again[i][j])
+			}
+		}
+	}
+}
+
+func TestSampleDelays_Unjittered(t *testing.T) {
+	r := New(time.Millisecond, 4*time.Millisecond, Rate(2))
+
+	want := []time.Duration{0, 2 * time.Millisecond, 4 * time.Millisecond, 4 * time.Millisecond}
+	for _, s := range r.SampleDelays(len(want), 2) {
+		for j := range want {
+			if s[j] != want[j] {
+				t.Fatalf("delay %d = %v, want %v", j, s[j], want[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package retry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RunShared is like Run, but concurrent calls with the same key share a
+// single retry loop, running fn of the first caller, and all receive its
+// error. It keeps a stampede of callers from each running their own loop
+// against a struggling dependency.
+//
+// The shared loop runs on a clone of r, so that loops of different keys
+// don't disturb each other and r merely serves as their template. It carries
+// the values of the first caller's context, and is cancelled once all its
+// callers gave up on their contexts; a caller whose context ends returns its
+// error without waiting.
+func (r *Retrier) RunShared(ctx context.Context, key string, fn func() error) error {
+	if fn == nil {
+		return ErrNilFunc
+	}
+	if r.shared == nil {
+		// Not created by New, Clone or Fork; there is nothing to share.
+		return r.Clone().Run(ctx, fn)
+	}
+
+	r.shared.mu.Lock()
+	if r.shared.calls == nil {
+		r.shared.calls = make(map[string]*sharedCall)
+	}
+	c, ok := r.shared.calls[key]
+	if !ok {
+		lctx, cancel := context.WithCancel(detach(ctx))
+		c = &sharedCall{done: make(chan struct{}), cancel: cancel}
+		r.shared.calls[key] = c
+
+		loop := r.Clone()
+		go func() {
+			defer cancel()
+			c.err = loop.Run(lctx, fn)
+
+			r.shared.mu.Lock()
+			r.shared.forget(key, c)
+			r.shared.mu.Unlock()
+			close(c.done)
+		}()
+	}
+	c.refs++
+	r.shared.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.err
+	case <-ctx.Done():
+		r.shared.mu.Lock()
+		c.refs--
+		if c.refs == 0 {
+			// Later callers start a loop of their own.
+			r.shared.forget(key, c)
+			c.cancel()
+		}
+		r.shared.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// sharedRuns are the loops of RunShared in progress, by key.
+type sharedRuns struct {
+	mu    sync.Mutex
+	calls map[string]*sharedCall
+}
+
+// forget removes c from the loops in progress, unless already replaced.
+func (s *sharedRuns) forget(key string, c *sharedCall) {
+	if s.calls[key] == c {
+		delete(s.calls, key)
+	}
+}
+
+// sharedCall is a loop of RunShared.
+type sharedCall struct {
+	done   chan struct{}
+	err    error
+	refs   int
+	cancel context.CancelFunc
+}
+
+// detached is a context with the values of its parent, but without its
+// cancellation and deadline.
+type detached struct {
+	parent context.Context
+}
+
+func detach(ctx context.Context) context.Context {
+	return detached{parent: ctx}
+}
+
+func (detached) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detached) Done() <-chan struct{}       { return nil }
+func (detached) Err() error                  { return nil }
+func (d detached) Value(key any) any         { return d.parent.Value(key) }
@@ -0,0 +1,75 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunShared(t *testing.T) {
+	ctx := context.Background()
+	r := New(time.Millisecond, time.Millisecond)
+
+	var loops, calls int32
+	release := make(chan struct{})
+	fn := func() error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			atomic.AddInt32(&loops, 1)
+			<-release
+			return io.EOF
+		}
+		return nil
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- r.RunShared(ctx, "config", fn)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if loops != 1 || calls != 2 {
+		t.Fatalf("got %d loops making %d calls, want a single loop making 2", loops, calls)
+	}
+}
+
+func TestRunShared_Cancel(t *testing.T) {
+	r := New(time.Hour, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int32
+	done := make(chan error)
+	go func() {
+		done <- r.RunShared(ctx, "key", func() error {
+			atomic.AddInt32(&calls, 1)
+			return io.EOF
+		})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+
+	// The abandoned loop doesn't capture later callers.
+	err := r.RunShared(context.Background(), "key", func() error { return nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+package retry
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// WaitSignal is like Sleep, but returns false as soon as one of sigs is
+// received. Without sigs it listens for os.Interrupt and SIGTERM.
+// The signal notification is stopped before WaitSignal returns.
+func (r *Retrier) WaitSignal(sigs ...os.Signal) bool {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), sigs...)
+	defer stop()
+
+	return r.Wait(ctx)
+}
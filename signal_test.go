@@ -0,0 +1,29 @@
+//go:build unix
+
+package retry
+
+import (
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWaitSignal(t *testing.T) {
+	r := New(time.Hour, time.Hour)
+	if !r.WaitSignal(syscall.SIGUSR1) {
+		t.Fatalf("first attempt not allowed")
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	}()
+
+	start := time.Now()
+	if r.WaitSignal(syscall.SIGUSR1) {
+		t.Fatalf("attempt allowed after signal")
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("WaitSignal took %v to notice the signal", took)
+	}
+}
@@ -0,0 +1,172 @@
+package retry
+
+import (
+	"context"
+	"time"
+)
+
+// StopReason is the reason Run stopped.
+type StopReason int
+
+const (
+	// StopNone means Run has not stopped yet.
+	StopNone StopReason = iota
+	// StopSuccess means an attempt succeeded.
+	StopSuccess
+	// StopAttempts means the attempts ran out.
+	StopAttempts
+	// StopTimeout means the deadline of the run passed.
+	StopTimeout
+	// StopCanceled means the context was cancelled.
+	StopCanceled
+	// StopClosed means the channel passed to RetryWhileOpen was closed, or
+	// the retrier was closed.
+	StopClosed
+	// StopAbort means WouldRetry rejected the error of an attempt.
+	StopAbort
+	// StopSameError means MaxSameError identical errors occurred in a row.
+	StopSameError
+	// StopBackoffRatio means MaxBackoffRatio was exceeded.
+	StopBackoffRatio
+	// StopPreflight means the Preflight check failed.
+	StopPreflight
+	// StopLifetime means LifetimeExecutions ran out.
+	StopLifetime
+	// StopSibling means another retrier tripped the AbortToken.
+	StopSibling
+)
+
+func (s StopReason) String() string {
+	switch s {
+	case StopNone:
+		return "none"
+	case StopSuccess:
+		return "success"
+	case StopAttempts:
+		return "attempts exhausted"
+	case StopTimeout:
+		return "timeout"
+	case StopCanceled:
+		return "canceled"
+	case StopClosed:
+		return "closed"
+	case StopAbort:
+		return "aborted"
+	case StopSameError:
+		return "same error"
+	case StopBackoffRatio:
+		return "backoff ratio exceeded"
+	case StopPreflight:
+		return "preflight failed"
+	case StopLifetime:
+		return "lifetime executions exhausted"
+	case StopSibling:
+		return "aborted by sibling"
+	default:
+		return "unknown"
+	}
+}
+
+// StopTrace describes the state of the stop conditions when Run stopped.
+type StopTrace struct {
+	Reason StopReason
+
+	// Attempts is the number of attempts made.
+	Attempts int
+
+	// AttemptsLeft is the number of remaining attempts, negative if
+	// unlimited.
+	AttemptsLeft int
+
+	// Deadline is the deadline of the run, zero if it had none.
+	Deadline time.Time
+
+	// TimeLeft is the time that was left until Deadline.
+	TimeLeft time.Duration
+
+	// LastErr is the error of the last attempt.
+	LastErr error
+
+	// Vetoed reports whether WouldRetry rejected LastErr.
+	Vetoed bool
+}
+
+// TiePrefer sets the reason reported when the attempts ran out and the
+// deadline of the run passed at the same time, either StopTimeout, the
+// default, or StopAttempts. Other reasons are ignored. The deadline is that
+// of the context, including Timeout, or of RunUntil.
+func TiePrefer(reason StopReason) Option {
+	return func(r *Retrier) {
+		if reason == StopTimeout || reason == StopAttempts {
+			r.tiePrefer = reason
+		}
+	}
+}
+
+// TraceStop makes Run record why it stopped, see StopTrace.
+func TraceStop() Option {
+	return func(r *Retrier) {
+		r.traceStop = true
+	}
+}
+
+// StopTrace returns the trace of the last Run. It is only recorded with
+// TraceStop.
+func (r *Retrier) StopTrace() StopTrace {
+	return r.stopTrace
+}
+
+// waitStopReason returns the reason a Wait with ctx returned false.
+func (r *Retrier) waitStopReason(ctx context.Context) StopReason {
+	timedOut := ctx.Err() == context.DeadlineExceeded ||
+		!r.until.IsZero() && !r.now().Before(r.until)
+	// Attempts also reads zero while the backoff before the last attempt is
+	// in progress; only a Wait refusing for the lack of attempts ties.
+	if timedOut && r.Attempts == 0 && !r.sleepCut && r.tiePrefer == StopAttempts {
+		return StopAttempts
+	}
+
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return StopTimeout
+	case context.Canceled:
+		return StopCanceled
+	}
+	select {
+	case <-r.open:
+		return StopClosed
+	case <-r.closed.done():
+		return StopClosed
+	case <-r.abortToken.done():
+		return StopSibling
+	default:
+	}
+	if timedOut {
+		return StopTimeout
+	}
+	if r.Attempts == 0 {
+		return StopAttempts
+	}
+	return StopCanceled
+}
+
+func (r *Retrier) recordStop(ctx context.Context, reason StopReason, last error, n int) {
+	if !r.traceStop {
+		return
+	}
+
+	t := StopTrace{
+		Reason:       reason,
+		Attempts:     n,
+		AttemptsLeft: r.Attempts,
+		LastErr:      last,
+		Vetoed:       last != nil && !r.WouldRetry(last),
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		t.Deadline = deadline
+		if left := time.Until(deadline); left > 0 {
+			t.TimeLeft = left
+		}
+	}
+	r.stopTrace = t
+}
@@ -0,0 +1,135 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestTraceStop(t *testing.T) {
+	ctx := context.Background()
+	errFatal := errors.New("fatal")
+
+	for _, tc := range []struct {
+		name   string
+		opts   []Option
+		reason StopReason
+		vetoed bool
+	}{
+		{"attempts", []Option{Attempts(3)}, StopAttempts, false},
+		{"timeout", []Option{Timeout(20 * time.Millisecond)}, StopTimeout, false},
+		{"cond", []Option{Cond(func(err error) bool { return err != errFatal })}, StopAbort, true},
+		{"same error", []Option{MaxSameError(2)}, StopSameError, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]Option{TraceStop()}, tc.opts...)
+			r := New(time.Millisecond, time.Millisecond, opts...)
+
+			var calls int
+			_ = r.Run(ctx, func() error {
+				calls++
+				if calls == 4 {
+					return errFatal
+				}
+				return io.EOF
+			})
+
+			tr := r.StopTrace()
+			if tr.Reason != tc.reason {
+				t.Fatalf("reason = %v, want %v", tr.Reason, tc.reason)
+			}
+			if tr.Vetoed != tc.vetoed {
+				t.Fatalf("vetoed = %v, want %v", tr.Vetoed, tc.vetoed)
+			}
+			if tr.Attempts != calls || tr.LastErr == nil {
+				t.Fatalf("unexpected trace: %+v", tr)
+			}
+		})
+	}
+}
+
+func TestTraceStop_Details(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(0, 0, Attempts(5), Timeout(time.Hour), AbortOn(io.ErrUnexpectedEOF), TraceStop())
+	_ = r.Run(ctx, func() error { return io.ErrUnexpectedEOF })
+
+	tr := r.StopTrace()
+	if tr.Reason != StopAbort || tr.Attempts != 1 || tr.AttemptsLeft != 4 {
+		t.Fatalf("unexpected trace: %+v", tr)
+	}
+	if tr.Deadline.IsZero() || tr.TimeLeft < time.Hour-time.Minute {
+		t.Fatalf("time left not recorded: %+v", tr)
+	}
+
+	r = New(0, 0, TraceStop())
+	_ = r.Run(ctx, func() error { return nil })
+	if tr := r.StopTrace(); tr.Reason != StopSuccess || tr.LastErr != nil {
+		t.Fatalf("unexpected trace: %+v", tr)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(10*time.Millisecond, 10*time.Millisecond, Timeout(35*time.Millisecond))
+
+	start := time.Now()
+	var calls int
+	err := r.Run(ctx, func() error {
+		calls++
+		return io.EOF
+	})
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if took := time.Since(start); took > 100*time.Millisecond {
+		t.Fatalf("run took %v", took)
+	}
+	if calls < 2 || calls > 4 {
+		t.Fatalf("calls = %d, want about 4", calls)
+	}
+}
+
+func TestTiePrefer(t *testing.T) {
+	for _, tc := range []struct {
+		opts []Option
+		want StopReason
+	}{
+		{nil, StopTimeout},
+		{[]Option{TiePrefer(StopTimeout)}, StopTimeout},
+		{[]Option{TiePrefer(StopAttempts)}, StopAttempts},
+		{[]Option{TiePrefer(StopCanceled)}, StopTimeout},
+	} {
+		// The only attempt outlasts the timeout: both limits are hit at once.
+		opts := append([]Option{Attempts(1), Timeout(time.Millisecond), TraceStop()}, tc.opts...)
+		r := New(0, 0, opts...)
+		_ = r.Run(context.Background(), func() error {
+			time.Sleep(5 * time.Millisecond)
+			return io.EOF
+		})
+		if got := r.StopTrace().Reason; got != tc.want {
+			t.Fatalf("got %v, want %v", got, tc.want)
+		}
+	}
+
+	// Without a tie, the limit actually hit is reported.
+	r := New(0, 0, Attempts(1), Timeout(time.Hour), TraceStop(), TiePrefer(StopTimeout))
+	_ = r.Run(context.Background(), func() error { return io.EOF })
+	if got := r.StopTrace().Reason; got != StopAttempts {
+		t.Fatalf("got %v, want %v", got, StopAttempts)
+	}
+
+	// The timeout cutting the backoff before the last attempt isn't a tie.
+	r = New(time.Hour, time.Hour, Attempts(2), Timeout(20*time.Millisecond), TraceStop(), TiePrefer(StopAttempts))
+	var calls int
+	_ = r.Run(context.Background(), func() error {
+		calls++
+		return io.EOF
+	})
+	if got := r.StopTrace().Reason; got != StopTimeout || calls != 1 {
+		t.Fatalf("got %v after %d attempts, want %v after 1", got, calls, StopTimeout)
+	}
+}
@@ -0,0 +1,28 @@
+package retry
+
+import "time"
+
+// Strategy computes the delay before a retry, replacing the exponential growth
+// of the retrier. n is the number of attempts made so far, so it is 1 for the
+// first retry. Jitter, Floor and Ceil still apply to the returned delay, and
+// the first attempt is still immediate.
+type Strategy func(n int) time.Duration
+
+// WithStrategy makes the retrier compute its delays with s.
+func WithStrategy(s Strategy) Option {
+	return func(r *Retrier) {
+		r.strategy = s
+	}
+}
+
+// Alternating returns a strategy that waits short between most attempts and
+// long before every everyN-th retry. It suits polling protocols that check
+// frequently but periodically back off to reduce load.
+func Alternating(short, long time.Duration, everyN int) Strategy {
+	return func(n int) time.Duration {
+		if everyN > 0 && n%everyN == 0 {
+			return long
+		}
+		return short
+	}
+}
@@ -0,0 +1,47 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAlternating(t *testing.T) {
+	ctx := context.Background()
+
+	const (
+		short = time.Millisecond
+		long  = 5 * time.Millisecond
+	)
+	r := New(0, long, WithStrategy(Alternating(short, long, 3)))
+
+	want := []time.Duration{0, short, short, long, short, short, long}
+	for i, w := range want {
+		r.Wait(ctx)
+		if r.slept != w {
+			t.Fatalf("attempt %d: delay %v, want %v", i+1, r.slept, w)
+		}
+	}
+}
+
+func TestAlternating_Jitter(t *testing.T) {
+	ctx := context.Background()
+
+	const (
+		short = time.Millisecond
+		long  = 10 * time.Millisecond
+	)
+	r := New(0, time.Second, WithStrategy(Alternating(short, long, 2)), Jitter(0.1))
+	r.Wait(ctx)
+
+	for i := 0; i < 10; i++ {
+		r.Wait(ctx)
+		d := r.slept
+		if i%2 == 1 && d < short*5 {
+			t.Fatalf("retry %d: long delay %v too short", i+1, d)
+		}
+		if i%2 == 0 && d > short*5 {
+			t.Fatalf("retry %d: short delay %v too long", i+1, d)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package retry
+
+import (
+	"context"
+	"sync"
+)
+
+// Switch is a kill switch shared by many retriers. While it is paused, Wait
+// blocks in every retrier using it, halting retry amplification across a
+// process at once. The zero value is an unpaused switch.
+type Switch struct {
+	mu     sync.Mutex
+	paused bool
+	resume chan struct{}
+}
+
+// criticalKey is the context key marking critical runs.
+type criticalKey struct{}
+
+// Critical returns a context marking the waits and runs using it as critical.
+// Critical waits ignore the Switch of the retrier, so critical operations keep
+// retrying while the rest of the traffic is paused.
+//
+// Criticality is a property of a single invocation rather than an option of
+// the retrier, so it can't be turned on for every run by accident: only
+// contexts derived from the one returned by Critical are affected.
+func Critical(ctx context.Context) context.Context {
+	return context.WithValue(ctx, criticalKey{}, true)
+}
+
+func isCritical(ctx context.Context) bool {
+	critical, _ := ctx.Value(criticalKey{}).(bool)
+	return critical
+}
+
+// WithSwitch makes the retrier obey s.
+func WithSwitch(s *Switch) Option {
+	return func(r *Retrier) {
+		r.sw = s
+	}
+}
+
+// Pause engages the switch.
+func (s *Switch) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.paused {
+		s.paused = true
+		s.resume = make(chan struct{})
+	}
+}
+
+// Unpause releases the switch, resuming all blocked retriers.
+func (s *Switch) Unpause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.paused {
+		s.paused = false
+		close(s.resume)
+	}
+}
+
+// Paused reports whether the switch is engaged.
+func (s *Switch) Paused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.paused
+}
+
+// wait blocks while the switch is engaged. It returns false if ctx is
+// cancelled or one of the stop channels, those of a retrier that end its
+// Wait, is closed first.
+func (s *Switch) wait(ctx context.Context, open, closed, aborted <-chan struct{}) bool {
+	for {
+		s.mu.Lock()
+		paused, resume := s.paused, s.resume
+		s.mu.Unlock()
+
+		if !paused {
+			return true
+		}
+		select {
+		case <-resume:
+		case <-ctx.Done():
+			return false
+		case <-open:
+			return false
+		case <-closed:
+			return false
+		case <-aborted:
+			return false
+		}
+	}
+}
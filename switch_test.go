@@ -0,0 +1,119 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSwitch(t *testing.T) {
+	ctx := context.Background()
+
+	var s Switch
+	r := New(0, 0, WithSwitch(&s))
+
+	s.Pause()
+	if !s.Paused() {
+		t.Fatalf("switch not paused")
+	}
+
+	done := make(chan bool)
+	go func() {
+		done <- r.Wait(ctx)
+	}()
+
+	select {
+	case <-done:
+		t.Fatalf("Wait returned while switch engaged")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	s.Unpause()
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Fatalf("attempt not allowed after release")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Wait still blocked after release")
+	}
+}
+
+func TestSwitch_Close(t *testing.T) {
+	ctx := context.Background()
+
+	for _, name := range []string{"Close", "AbortToken", "RetryWhileOpen"} {
+		var s Switch
+		s.Pause()
+
+		token := NewAbortToken()
+		open := make(chan struct{})
+		r := New(0, 0, WithSwitch(&s), WithAbortToken(token), RetryWhileOpen(open))
+
+		done := make(chan bool)
+		go func() { done <- r.Wait(ctx) }()
+		time.Sleep(10 * time.Millisecond)
+
+		switch name {
+		case "Close":
+			_ = r.Close()
+		case "AbortToken":
+			token.Abort(nil)
+		case "RetryWhileOpen":
+			close(open)
+		}
+		select {
+		case ok := <-done:
+			if ok {
+				t.Fatalf("%s: attempt allowed while stopped", name)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s: Wait still blocked on the paused switch", name)
+		}
+		s.Unpause()
+	}
+}
+
+func TestSwitch_Context(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var s Switch
+	s.Pause()
+	defer s.Unpause()
+
+	r := New(0, 0, WithSwitch(&s))
+	if r.Wait(ctx) {
+		t.Fatalf("attempt allowed while switch engaged")
+	}
+}
+
+func TestCritical(t *testing.T) {
+	var s Switch
+	s.Pause()
+	defer s.Unpause()
+
+	r := New(0, 0, WithSwitch(&s))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var calls int
+	err := r.Run(Critical(ctx), func() error {
+		calls++
+		if calls < 3 {
+			return context.DeadlineExceeded
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("critical run blocked by switch: err = %v, calls = %d", err, calls)
+	}
+
+	// Other runs are still paused.
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if r.Wait(ctx) {
+		t.Fatalf("non-critical wait ignored the switch")
+	}
+}
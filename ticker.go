@@ -0,0 +1,181 @@
+package retry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BackoffTicker delivers ticks at the intervals of a retrier.
+// Unlike time.Ticker, the interval grows with every tick.
+type BackoffTicker struct {
+	// C delivers the ticks. Like time.Ticker, it is buffered by one tick and
+	// ticks are dropped for slow receivers. It is closed once the retrier
+	// gives up or the ticker is stopped.
+	C <-chan time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Ticker returns a ticker firing after every Wait of r, the first time
+// immediately. The ticker owns r until it is stopped, at which point it is
+// safe to use r again.
+func (r *Retrier) Ticker(ctx context.Context) *BackoffTicker {
+	ctx, cancel := context.WithCancel(ctx)
+	c := make(chan time.Time, 1)
+	t := &BackoffTicker{
+		C:      c,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(t.done)
+		defer close(c)
+
+		for r.Wait(ctx) {
+			select {
+			case c <- r.now():
+			default:
+			}
+		}
+	}()
+
+	return t
+}
+
+// Stop turns off the ticker and waits for it to release its retrier.
+func (t *BackoffTicker) Stop() {
+	t.cancel()
+	<-t.done
+}
+
+// Permits returns a channel delivering a permit after every backoff delay of
+// r, the first one immediately, so that a worker can range over it to act at
+// a backing-off cadence, e.g. for progressively rarer health checks. Unlike
+// Ticker, permits aren't dropped: the next delay starts once the previous
+// permit was received.
+//
+// The cadence is decoupled from success and failure: the delay grows only
+// when the worker calls AdvancePermits, and ResetPermits starts over from the
+// immediate permit. Both take effect on the delay in progress. Floor, Ceil,
+// Rate, Jitter and Attempts apply as for Wait. The channel is closed once the
+// attempts run out or ctx ends, and like Wait when r is closed or aborted or
+// the channel of RetryWhileOpen is closed, also during a delay. Permits owns
+// r until then.
+func (r *Retrier) Permits(ctx context.Context) <-chan struct{} {
+	ps := &permitState{wake: make(chan struct{}, 1)}
+	r.permits = ps
+	c := make(chan struct{})
+
+	go func() {
+		defer close(c)
+
+		grow := r.growAfterFailure
+		r.growAfterFailure = true
+		defer func() { r.growAfterFailure = grow }()
+
+		for r.stillOpen(ctx) && r.Attempts != 0 {
+			if r.Attempts > 0 {
+				r.Attempts--
+			}
+
+			start := r.now()
+			prev := r.Delay
+			d := ps.apply(r, prev, 0)
+			for waiting := true; waiting; {
+				t := time.NewTimer(d - r.since(start))
+				select {
+				case <-t.C:
+					waiting = false
+				case <-ps.wake:
+					d = ps.apply(r, prev, d)
+				case <-ctx.Done():
+					t.Stop()
+					return
+				case <-r.open:
+					t.Stop()
+					return
+				case <-r.closed.done():
+					t.Stop()
+					return
+				case <-r.abortToken.done():
+					t.Stop()
+					return
+				}
+				t.Stop()
+			}
+			r.advance(d)
+
+			select {
+			case c <- struct{}{}:
+			case <-ctx.Done():
+				return
+			case <-r.open:
+				return
+			case <-r.closed.done():
+				return
+			case <-r.abortToken.done():
+				return
+			}
+		}
+	}()
+
+	return c
+}
+
+// AdvancePermits makes the delay before the next permit of Permits grow.
+func (r *Retrier) AdvancePermits() {
+	r.permits.set(func(ps *permitState) { ps.advance = true })
+}
+
+// ResetPermits makes the next permit of Permits come immediately and the
+// following ones back off from the start again.
+func (r *Retrier) ResetPermits() {
+	r.permits.set(func(ps *permitState) { ps.reset = true })
+}
+
+// permitState is the feedback of a worker to Permits.
+type permitState struct {
+	mu      sync.Mutex
+	advance bool
+	reset   bool
+
+	// wake signals feedback given while a delay is in progress.
+	wake chan struct{}
+}
+
+func (ps *permitState) set(fn func(*permitState)) {
+	if ps == nil {
+		return
+	}
+	ps.mu.Lock()
+	fn(ps)
+	ps.mu.Unlock()
+
+	select {
+	case ps.wake <- struct{}{}:
+	default:
+	}
+}
+
+// apply consumes the pending feedback and returns the delay before the next
+// permit, computed from prev, the delay before the last one. d is the delay
+// computed so far, zero if none was.
+func (ps *permitState) apply(r *Retrier, prev, d time.Duration) time.Duration {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	switch {
+	case ps.reset:
+		r.Reset()
+		r.Delay = r.next()
+	case ps.advance || d == 0:
+		r.Delay = prev
+		r.failed = ps.advance
+		r.Delay = r.next()
+	}
+	ps.reset, ps.advance = false, false
+	return r.Delay
+}
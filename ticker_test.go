@@ -0,0 +1,126 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTicker(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(5*time.Millisecond, time.Second, Rate(2))
+	tk := r.Ticker(ctx)
+
+	var ticks []time.Time
+	for i := 0; i < 4; i++ {
+		ticks = append(ticks, <-tk.C)
+	}
+	tk.Stop()
+
+	// Intervals are 10ms, 20ms and 40ms.
+	want := 10 * time.Millisecond
+	for i := 1; i < len(ticks); i++ {
+		if d := ticks[i].Sub(ticks[i-1]); d < want || d > want*3 {
+			t.Fatalf("interval %d = %v, want about %v", i, d, want)
+		}
+		want *= 2
+	}
+
+	if _, ok := <-tk.C; ok {
+		t.Fatalf("tick delivered after Stop")
+	}
+}
+
+func TestTicker_Exhausted(t *testing.T) {
+	ctx := context.Background()
+
+	r := New(5*time.Millisecond, 5*time.Millisecond, Attempts(3))
+	tk := r.Ticker(ctx)
+	defer tk.Stop()
+
+	var n int
+	for range tk.C {
+		n++
+	}
+	if n != 3 {
+		t.Fatalf("got %d ticks, want 3", n)
+	}
+}
+
+func TestPermits(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const floor = 20 * time.Millisecond
+	r := New(floor, time.Second, Rate(2))
+	permits := r.Permits(ctx)
+
+	last := time.Now()
+	next := func(want time.Duration) {
+		t.Helper()
+		<-permits
+		got := time.Since(last)
+		last = time.Now()
+		if got < want-floor/4 || got > want+floor {
+			t.Fatalf("permit after %v, want %v", got, want)
+		}
+	}
+
+	next(0)
+	r.AdvancePermits()
+	next(2 * floor)
+	// Without feedback the cadence holds.
+	next(2 * floor)
+	r.ResetPermits()
+	next(0)
+	next(floor)
+	// Feedback applies to the delay in progress.
+	time.Sleep(floor / 4)
+	r.AdvancePermits()
+	next(2 * floor)
+
+	cancel()
+	for range permits {
+	}
+}
+
+func TestPermits_Attempts(t *testing.T) {
+	r := New(0, 0, Attempts(3))
+	var n int
+	for range r.Permits(context.Background()) {
+		n++
+	}
+	if n != 3 {
+		t.Fatalf("got %d permits, want 3", n)
+	}
+}
+
+func TestPermits_Close(t *testing.T) {
+	for _, name := range []string{"Close", "AbortToken", "RetryWhileOpen"} {
+		token := NewAbortToken()
+		open := make(chan struct{})
+		r := New(time.Hour, time.Hour, WithAbortToken(token), RetryWhileOpen(open))
+		c := r.Permits(context.Background())
+		if _, ok := <-c; !ok {
+			t.Fatalf("%s: no first permit", name)
+		}
+
+		switch name {
+		case "Close":
+			_ = r.Close()
+		case "AbortToken":
+			token.Abort(nil)
+		case "RetryWhileOpen":
+			close(open)
+		}
+		select {
+		case _, ok := <-c:
+			if ok {
+				t.Fatalf("%s: got a permit after stopping", name)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s: delay in progress not interrupted", name)
+		}
+	}
+}
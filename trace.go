@@ -0,0 +1,49 @@
+package retry
+
+import (
+	"context"
+	"runtime/trace"
+)
+
+// WithTrace makes Run show up in execution traces viewed with go tool trace:
+// every run becomes a task, and every attempt and backoff a region of it
+// labelled with the attempt number and the delay. It costs nothing while
+// tracing isn't active.
+func WithTrace() Option {
+	return func(r *Retrier) {
+		r.trace = true
+	}
+}
+
+// traceWait calls Wait before attempt n+1, in a trace region if tracing.
+func (r *Retrier) traceWait(ctx context.Context, n int) bool {
+	if !r.trace || !trace.IsEnabled() {
+		return r.Wait(ctx)
+	}
+
+	var ok bool
+	trace.WithRegion(ctx, "retry.backoff", func() {
+		ok = r.Wait(ctx)
+	})
+	if ok {
+		trace.Logf(ctx, "retry", "attempt %d after %v", n+1, r.slept)
+	}
+	return ok
+}
+
+// traceAttempt calls fn with actx for attempt n, in a trace region of ctx if
+// tracing.
+func (r *Retrier) traceAttempt(ctx, actx context.Context, n int, fn func(ctx context.Context) error) error {
+	if !r.trace || !trace.IsEnabled() {
+		return fn(actx)
+	}
+
+	var err error
+	trace.WithRegion(ctx, "retry.attempt", func() {
+		err = fn(actx)
+	})
+	if err != nil {
+		trace.Logf(ctx, "retry", "attempt %d failed: %v", n, err)
+	}
+	return err
+}
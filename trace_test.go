@@ -0,0 +1,37 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"runtime/trace"
+	"testing"
+	"time"
+)
+
+func TestWithTrace(t *testing.T) {
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Skipf("tracing unavailable: %v", err)
+	}
+
+	r := New(time.Millisecond, time.Millisecond, WithTrace())
+	var calls int
+	err := r.Run(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return io.EOF
+		}
+		return nil
+	})
+	trace.Stop()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, label := range []string{"retry.Run", "retry.attempt", "retry.backoff"} {
+		if !bytes.Contains(buf.Bytes(), []byte(label)) {
+			t.Fatalf("trace has no %q", label)
+		}
+	}
+}
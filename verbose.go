@@ -0,0 +1,24 @@
+package retry
+
+// Verbose makes the retrier report every decision it takes to fn, for
+// diagnosing a configuration that doesn't behave as expected. The steps and
+// their details are:
+//
+//   - "delay.raw", time.Duration: the grown delay before jitter and clamping.
+//   - "delay.jittered", time.Duration: the delay after jitter.
+//   - "delay.clamped", time.Duration: the delay after clamping to Floor and
+//     Ceil, which becomes Delay.
+//   - "sleep", time.Duration: the time Wait actually sleeps, after overrides,
+//     FixedCadence, StaggerStart and the deadline of RunUntil.
+//   - "retry", StopReason: the verdict of Run on an attempt, StopNone if
+//     another attempt follows.
+//   - "stop", StopReason: the reason Run stopped.
+//
+// Delays of a fixed retrier aren't computed and thus not reported. fn is
+// called synchronously from Wait and Run. Without Verbose the checks cost
+// nothing but a nil comparison.
+func Verbose(fn func(step string, detail any)) Option {
+	return func(r *Retrier) {
+		r.verbose = fn
+	}
+}
@@ -0,0 +1,58 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestVerbose(t *testing.T) {
+	type step struct {
+		name   string
+		detail any
+	}
+	var steps []step
+	record := func(name string, detail any) {
+		steps = append(steps, step{name, detail})
+	}
+	schedule := func(ctx context.Context, d time.Duration) error { return nil }
+
+	r := New(time.Second, time.Hour, Rate(2), Jitter(0), Attempts(3), Verbose(record), WithScheduler(schedule))
+	var n int
+	err := r.Run(context.Background(), func() error {
+		n++
+		if n < 3 {
+			return io.EOF
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wait := func(d time.Duration) []step {
+		return []step{
+			{"delay.raw", d},
+			{"delay.jittered", d},
+			{"delay.clamped", d},
+			{"sleep", d},
+		}
+	}
+	var want []step
+	want = append(want, wait(0)...)
+	want = append(want, step{"retry", StopNone})
+	want = append(want, wait(2*time.Second)...)
+	want = append(want, step{"retry", StopNone})
+	want = append(want, wait(4*time.Second)...)
+	want = append(want, step{"retry", StopSuccess}, step{"stop", StopSuccess})
+
+	if len(steps) != len(want) {
+		t.Fatalf("got %v,\nwant %v", steps, want)
+	}
+	for i := range want {
+		if steps[i] != want[i] {
+			t.Fatalf("step %d: got %v, want %v", i, steps[i], want[i])
+		}
+	}
+}